@@ -1,20 +1,22 @@
 package content
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/adrg/frontmatter"
-	"github.com/alecthomas/chroma/v2/formatters/html"
 	obsidian "github.com/powerman/goldmark-obsidian"
 	"github.com/yuin/goldmark"
-
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
 	alertcallouts "github.com/zmtcreative/gm-alert-callouts"
 )
 
@@ -24,57 +26,361 @@ type ContentItem[T any] struct {
 	Content string
 	HTML    string
 	Slug    string
+	// TOC holds the item's headings, nested by level.
+	TOC []Heading
+	// Summary is a plain-text summary: the frontmatter description if set,
+	// otherwise the text before a <!--more--> line, otherwise the opening words.
+	Summary string
+	// Excerpt is the HTML before a <!--more--> line. Without one it is the
+	// frontmatter description, or the first paragraph if that is empty.
+	Excerpt     string
+	WordCount   int
+	ReadingTime time.Duration
+	// Date is the item's date frontmatter field, or the 2024-01-02- prefix of
+	// its file name, or its modification time. See the Date type.
+	Date time.Time
+	// Draft and PublishDate come from the draft and publishDate frontmatter
+	// keys. Drafts and items with a future PublishDate are hidden unless
+	// IncludeDrafts is used.
+	Draft       bool
+	PublishDate time.Time
+	// Links holds the slugs of the items this item links to with wikilinks,
+	// and Backlinks the slugs of the items linking to it. Both leave out
+	// items that are not published yet.
+	Links     []string
+	Backlinks []string
+	// Series and SeriesPart come from the series and seriesPart frontmatter
+	// keys. Items of a series are ordered by SeriesPart, then Date.
+	Series     string
+	SeriesPart int
 }
 
 type ContentMeta[T any] struct {
 	Meta func() T
 }
 
-var store = make(map[reflect.Type]any)
+// collection holds the loaded items for a type along with a slug index.
+type collection[T any] struct {
+	items  []ContentItem[T]
+	bySlug map[string]int
+	// lastPublish is the latest PublishDate of any item. Until it passes,
+	// scheduled items have to be filtered out on every read.
+	lastPublish   time.Time
+	showScheduled bool
+	taxonomies    map[string]*taxonomy
+	series        map[string][]int
+	brokenLinks   []BrokenLink
+	search        *searchIndex
+}
 
-// GetItems returns all content items for a given type T.
-// LoadItems must be called first to populate the store.
-func GetItems[T any]() ([]ContentItem[T], error) {
+func newCollection[T any](items []ContentItem[T], showScheduled bool) *collection[T] {
+	c := &collection[T]{
+		items:         items,
+		bySlug:        make(map[string]int, len(items)),
+		showScheduled: showScheduled,
+		taxonomies:    indexTaxonomies(items),
+		series:        indexSeries(items),
+	}
+	for i, item := range items {
+		c.bySlug[item.Slug] = i
+		if item.PublishDate.After(c.lastPublish) {
+			c.lastPublish = item.PublishDate
+		}
+	}
+	return c
+}
+
+func (c *collection[T]) published(item ContentItem[T], now time.Time) bool {
+	return c.showScheduled || !item.PublishDate.After(now)
+}
+
+// visible returns the items that are published at now, in a new slice that
+// callers are free to reorder.
+func (c *collection[T]) visible(now time.Time) []ContentItem[T] {
+	if c.showScheduled || !c.lastPublish.After(now) {
+		return slices.Clone(c.items)
+	}
+
+	var items []ContentItem[T]
+	for _, item := range c.items {
+		if c.published(item, now) {
+			items = append(items, c.view(item, now))
+		}
+	}
+	return items
+}
+
+// view returns item as it is seen at now, with its Links and Backlinks
+// limited to the items published at now.
+func (c *collection[T]) view(item ContentItem[T], now time.Time) ContentItem[T] {
+	if c.showScheduled || !c.lastPublish.After(now) {
+		return item
+	}
+
+	hidden := func(slug string) bool {
+		i, ok := c.bySlug[slug]
+		return ok && !c.published(c.items[i], now)
+	}
+	item.Links = slices.DeleteFunc(slices.Clone(item.Links), hidden)
+	item.Backlinks = slices.DeleteFunc(slices.Clone(item.Backlinks), hidden)
+	return item
+}
+
+// store maps each content type to its current *collection. Collections are
+// never mutated once stored; a reload swaps in a new one.
+var (
+	storeMu sync.RWMutex
+	store   = make(map[reflect.Type]any)
+)
+
+// ErrNotFound is matched by errors.Is for any NotFoundError.
+var ErrNotFound = errors.New("content not found")
+
+// NotFoundError is returned by GetItem when no item has the requested slug.
+type NotFoundError struct {
+	Type reflect.Type
+	Slug string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no %v found with slug %q", e.Type, e.Slug)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func getCollection[T any]() (*collection[T], error) {
 	t := reflect.TypeOf((*T)(nil)).Elem()
 
-	items, ok := store[t].([]ContentItem[T])
+	storeMu.RLock()
+	c, ok := store[t].(*collection[T])
+	storeMu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("no items found for type %v, ensure LoadItems was called", t)
 	}
 
-	return items, nil
+	return c, nil
+}
+
+// GetItems returns all content items for a given type T.
+// LoadItems must be called first to populate the store.
+func GetItems[T any]() ([]ContentItem[T], error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.visible(time.Now()), nil
+}
+
+// GetItem returns the content item of type T with the given slug.
+// If no item matches, the error is a *NotFoundError.
+func GetItem[T any](slug string) (ContentItem[T], error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return ContentItem[T]{}, err
+	}
+
+	now := time.Now()
+	i, ok := c.bySlug[slug]
+	if !ok || !c.published(c.items[i], now) {
+		return ContentItem[T]{}, &NotFoundError{Type: reflect.TypeOf((*T)(nil)).Elem(), Slug: slug}
+	}
+
+	return c.view(c.items[i], now), nil
 }
 
 type loadConfig struct {
-	imageCallback func(imageTag string) string
-	resolveLink   func(target string) string
+	imageCallback   func(imageTag string) string
+	imageHook       ImageHook
+	resolveLink     func(target string) string
+	linkPrefix      string
+	strict          bool
+	rejectUnknown   bool
+	watchDir        string
+	extensions      []goldmark.Extender
+	parserOptions   []parser.Option
+	rendererOptions []renderer.Option
+	highlightStyle  string
+	headingAnchors  bool
+	includeDrafts   bool
+	permalink       string
+	// responsiveImages is set by ResponsiveImages.
+	responsiveImages *ImageOptions
 }
 
 type LoadOpt func(*loadConfig)
 
+// ImagePostProcess sets a callback that can rewrite the HTML of every
+// rendered image. OnImage gives the image as a struct instead.
 func ImagePostProcess(imageCallback func(imageTag string) string) LoadOpt {
 	return func(config *loadConfig) {
 		config.imageCallback = imageCallback
 	}
 }
 
+// ResolveLink overrides how wikilink targets are turned into URLs.
+// By default they are resolved to the slugs of the loaded items, see LinkPrefix.
+// With ResolveLink no link is reported as broken, since targets need not be
+// loaded items.
 func ResolveLink(resolveLink func(target string) string) LoadOpt {
 	return func(config *loadConfig) {
 		config.resolveLink = resolveLink
 	}
 }
 
+// LinkPrefix sets the URL prefix of resolved wikilinks, e.g. "/blog/" to link
+// [[some-post]] to /blog/some-post. Defaults to "/<dirName>/".
+func LinkPrefix(prefix string) LoadOpt {
+	return func(config *loadConfig) {
+		config.linkPrefix = prefix
+	}
+}
+
+// Strict makes LoadItems fail on problems that are otherwise logged as
+// warnings, such as wikilinks to items that do not exist.
+func Strict() LoadOpt {
+	return func(config *loadConfig) {
+		config.strict = true
+	}
+}
+
+// RejectUnknownKeys makes LoadItems and LoadData fail on frontmatter keys
+// that match no field of the content type, instead of logging them. Strict
+// implies it.
+func RejectUnknownKeys() LoadOpt {
+	return func(config *loadConfig) {
+		config.rejectUnknown = true
+	}
+}
+
+// Extensions adds goldmark extensions to the markdown pipeline,
+// after the default alert callout and Obsidian extensions.
+func Extensions(extensions ...goldmark.Extender) LoadOpt {
+	return func(config *loadConfig) {
+		config.extensions = append(config.extensions, extensions...)
+	}
+}
+
+// ReplaceExtensions replaces the default alert callout and Obsidian extensions.
+// Image and link rewriting and syntax highlighting are always enabled.
+func ReplaceExtensions(extensions ...goldmark.Extender) LoadOpt {
+	return func(config *loadConfig) {
+		config.extensions = extensions
+	}
+}
+
+// ParserOptions adds goldmark parser options to the markdown pipeline.
+func ParserOptions(opts ...parser.Option) LoadOpt {
+	return func(config *loadConfig) {
+		config.parserOptions = append(config.parserOptions, opts...)
+	}
+}
+
+// RendererOptions adds goldmark renderer options to the markdown pipeline.
+func RendererOptions(opts ...renderer.Option) LoadOpt {
+	return func(config *loadConfig) {
+		config.rendererOptions = append(config.rendererOptions, opts...)
+	}
+}
+
+// HighlightStyle sets the chroma style used for code blocks.
+// Defaults to DefaultHighlightStyle. The matching CSS is served by HighlightCSS.
+func HighlightStyle(style string) LoadOpt {
+	return func(config *loadConfig) {
+		config.highlightStyle = style
+	}
+}
+
+// HeadingAnchors appends a permalink anchor to every rendered heading:
+// <a class="heading-anchor" href="#id">#</a>.
+func HeadingAnchors() LoadOpt {
+	return func(config *loadConfig) {
+		config.headingAnchors = true
+	}
+}
+
+// IncludeDrafts loads items marked as drafts and shows items whose
+// publishDate has not passed yet. It is the default in DevMode.
+func IncludeDrafts() LoadOpt {
+	return func(config *loadConfig) {
+		config.includeDrafts = true
+	}
+}
+
+// Watch makes LoadItems read content from dir on disk instead of the
+// filesystem it was given, and reload it whenever a file changes.
+// It is meant for development, see DevMode.
+func Watch(dir string) LoadOpt {
+	return func(config *loadConfig) {
+		config.watchDir = dir
+	}
+}
+
 // LoadItems loads all content items for a given type T from the provided filesystem.
 // The items will be loaded from the specified directory.
+// Items from a previous load stay available until the new load succeeds;
+// if it fails they are kept and the error is returned.
 func LoadItems[T any](fsys fs.FS, dirName string, opts ...LoadOpt) error {
-	cfg := loadConfig{}
+	cfg := loadConfig{
+		extensions: []goldmark.Extender{
+			alertcallouts.NewAlertCallouts(),
+			obsidian.NewObsidian(),
+		},
+		highlightStyle: DefaultHighlightStyle,
+		includeDrafts:  DevMode(),
+	}
 	for _, opt := range opts {
 		opt(&cfg)
 	}
+
+	stopWatching[T]()
+
+	if cfg.watchDir != "" {
+		fsys = os.DirFS(cfg.watchDir)
+	}
+
+	if err := loadItems[T](fsys, dirName, cfg); err != nil {
+		return err
+	}
+
+	if cfg.watchDir != "" {
+		watch[T](fsys, dirName, cfg, loadItems[T])
+	}
+
+	return nil
+}
+
+// parsedItem is an item whose markdown has been parsed but not rendered yet.
+type parsedItem[T any] struct {
+	path    string
+	doc     *document
+	aliases []string
+	item    ContentItem[T]
+}
+
+func loadItems[T any](fsys fs.FS, dirName string, cfg loadConfig) error {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	delete(store, t)
 
-	var items []ContentItem[T]
+	if cfg.permalink != "" {
+		if err := checkPermalink(cfg.permalink, t); err != nil {
+			return fmt.Errorf("invalid permalink pattern %q: %w", cfg.permalink, err)
+		}
+	}
+
+	var parsed []parsedItem[T]
+	// errs collects the frontmatter errors of every file.
+	var errs []error
+
+	images := &markdownImages{
+		callback: cfg.imageCallback,
+		hook:     cfg.imageHook,
+	}
+	if cfg.responsiveImages != nil {
+		images.responsive = &imageProcessor{opts: *cfg.responsiveImages, fsys: fsys}
+	}
+	markdown := newMarkdown(cfg, images)
 
 	slog.Info("Loading content", "type", t, "dir", dirName)
 	err := fs.WalkDir(fsys, dirName, func(path string, d fs.DirEntry, err error) error {
@@ -99,47 +405,31 @@ func LoadItems[T any](fsys fs.FS, dirName string, opts ...LoadOpt) error {
 		meta := reflect.New(t).Interface()
 
 		// Parse frontmatter
-		remainder, err := frontmatter.Parse(strings.NewReader(string(content)), meta)
+		var builtin builtinFrontmatter
+		var keys map[string]any
+		remainder, err := parseFrontmatter(content, meta, &builtin, &keys)
 		if err != nil {
-			return fmt.Errorf("failed to parse frontmatter in %s: %w", path, err)
+			errs = append(errs, decodeErrors(path, content, frontmatterOffset(content), t, err)...)
+			return nil
 		}
 
-		var htmlWriter bytes.Buffer
-		var cssWriter bytes.Buffer
+		if builtin.draft() && !cfg.includeDrafts {
+			slog.Debug("Skipping draft", "path", path)
+			return nil
+		}
 
-		// Convert markdown to HTML
-		markdown := goldmark.New(
-			goldmark.WithExtensions(
-				alertcallouts.NewAlertCallouts(),
-				obsidian.NewObsidian(),
-				&markdownImages{
-					parentPath:  filepath.Dir(filepath.Join("/content", path)),
-					callback:    cfg.imageCallback,
-					resolveLink: cfg.resolveLink,
-				},
-				highlighting.NewHighlighting(
-					highlighting.WithStyle("rrt"),
-					highlighting.WithFormatOptions(html.WithClasses(true), html.WithAllClasses(true)),
-					highlighting.WithCSSWriter(&cssWriter),
-					highlighting.WithGuessLanguage(true),
-				),
-			),
-		)
-
-		markdown.Convert(remainder, &htmlWriter)
-
-		htmlWriter.Write([]byte("<style>"))
-		b, err := cssWriter.WriteTo(&htmlWriter)
+		publishDate, err := builtin.publishDate()
 		if err != nil {
-			return fmt.Errorf("failed to write CSS to HTML: %w", err)
+			errs = append(errs, &FrontmatterError{Path: path, Line: keyLine(content, "publishDate"), Err: err})
 		}
 
-		if b == 0 {
-			slog.Warn("no CSS written")
+		if invalid := validateFrontmatter(path, content, meta, keys, cfg.strict || cfg.rejectUnknown); len(invalid) > 0 {
+			errs = append(errs, invalid...)
+			return nil
 		}
-		htmlWriter.Write([]byte("</style>"))
 
-		html := htmlWriter.Bytes()
+		doc := parseMarkdown(markdown, remainder)
+		headings := doc.headings(cfg.headingAnchors)
 
 		// Get relative path without extension for routing
 		relPath := strings.TrimSuffix(strings.TrimPrefix(path, dirName+"/"), ".md")
@@ -147,14 +437,37 @@ func LoadItems[T any](fsys fs.FS, dirName string, opts ...LoadOpt) error {
 		// Handle index files by removing the /index suffix
 		relPath = strings.TrimSuffix(relPath, "/index")
 
-		item := ContentItem[T]{
-			Meta:    reflect.ValueOf(meta).Elem().Interface().(T),
-			Content: string(remainder),
-			HTML:    string(html),
-			Slug:    relPath,
+		date := itemDate(reflect.ValueOf(meta), relPath, d)
+
+		slug := relPath
+		switch {
+		case builtin.slug() != "":
+			slug = slugOverride(builtin.slug())
+		case cfg.permalink != "":
+			slug = expandPermalink(cfg.permalink, reflect.ValueOf(meta), relPath, date)
+		}
+		if slug == "" {
+			errs = append(errs, &FrontmatterError{Path: path, Line: keyLine(content, "slug"), Err: errors.New("empty slug")})
+			return nil
 		}
 
-		items = append(items, item)
+		parsed = append(parsed, parsedItem[T]{
+			path:    path,
+			doc:     doc,
+			aliases: builtin.aliases(),
+			item: ContentItem[T]{
+				Meta:    reflect.ValueOf(meta).Elem().Interface().(T),
+				Content: string(remainder),
+				Slug:    slug,
+				TOC:     nestHeadings(headings),
+				Date:    date,
+
+				Draft:       builtin.draft(),
+				PublishDate: publishDate,
+				Series:      builtin.series(),
+				SeriesPart:  builtin.seriesPart(),
+			},
+		})
 		return nil
 	})
 
@@ -162,6 +475,71 @@ func LoadItems[T any](fsys fs.FS, dirName string, opts ...LoadOpt) error {
 		return fmt.Errorf("failed to load content items: %w", err)
 	}
 
-	store[t] = items
+	slugs := make([]string, len(parsed))
+	paths := make([]string, len(parsed))
+	for i, p := range parsed {
+		slugs[i], paths[i] = p.item.Slug, p.path
+	}
+	errs = append(errs, duplicateSlugs(slugs, paths)...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load content items: %w", errors.Join(errs...))
+	}
+
+	links := linkItems(parsed)
+	if cfg.resolveLink != nil {
+		// Targets outside the collection are the custom resolver's to handle.
+		links.broken = nil
+	}
+	if len(links.broken) > 0 {
+		if cfg.strict {
+			return &BrokenLinksError{Links: links.broken}
+		}
+		for _, l := range links.broken {
+			slog.Warn("broken wikilink", "path", l.Path, "target", l.Target)
+		}
+	}
+
+	images.resolveWikilink = links.resolver(cfg, dirName)
+
+	items := make([]ContentItem[T], 0, len(parsed))
+	bodies := make([]string, 0, len(parsed))
+	for _, p := range parsed {
+		// Convert markdown to HTML
+		images.parentPath = filepath.Dir(filepath.Join("/content", p.path))
+		images.path = p.path
+		html, err := p.doc.render(markdown)
+		if err != nil {
+			return fmt.Errorf("failed to convert markdown in %s: %w", p.path, err)
+		}
+
+		summary, err := p.doc.summarize(markdown, metaString(reflect.ValueOf(p.item.Meta), "description"))
+		if err != nil {
+			return fmt.Errorf("failed to summarize %s: %w", p.path, err)
+		}
+
+		item := p.item
+		item.HTML = html
+		item.Summary = summary.text
+		item.Excerpt = summary.excerpt
+		item.WordCount = summary.wordCount
+		item.ReadingTime = summary.readingTime
+
+		items = append(items, item)
+		bodies = append(bodies, summary.body)
+	}
+
+	if err := runAfterLoad(items, paths); err != nil {
+		return err
+	}
+
+	c := newCollection(items, cfg.includeDrafts)
+	c.brokenLinks = links.broken
+	c.search = indexSearch(items, bodies)
+
+	storeMu.Lock()
+	store[t] = c
+	storeMu.Unlock()
+
 	return nil
 }
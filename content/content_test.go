@@ -1,10 +1,30 @@
 package content
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/labstack/echo/v4"
+	"github.com/yuin/goldmark/extension"
 )
 
 type Post struct {
@@ -113,9 +133,13 @@ func TestLoadAndGetItems(t *testing.T) {
 		t.Errorf("Expected content to contain '%s', got '%s'", expectedContent, item.Content)
 	}
 
-	if !strings.Contains(item.HTML, "<h2>It is markdown.</h2>") {
+	if !strings.Contains(item.HTML, `<h2 id="it-is-markdown">It is markdown.</h2>`) {
 		t.Error("Expected HTML to contain markdown conversion")
 	}
+
+	if strings.Contains(item.HTML, "<style>") {
+		t.Error("Expected HTML to contain no highlight stylesheet")
+	}
 }
 
 func TestIndex(t *testing.T) {
@@ -152,6 +176,1317 @@ func TestIndex(t *testing.T) {
 	}
 }
 
+func TestGetItem(t *testing.T) {
+	fsys := setupTestFS()
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	item, err := GetItem[Post]("2024/test-1-two")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if item.Meta.Title != "Index Post" {
+		t.Errorf("Expected title 'Index Post', got '%s'", item.Meta.Title)
+	}
+
+	_, err = GetItem[Post]("does-not-exist")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || notFound.Slug != "does-not-exist" {
+		t.Errorf("Expected *NotFoundError for slug 'does-not-exist', got %v", err)
+	}
+}
+
+func TestGetItemsReturnsCopy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\ntitle: A\n---\na")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\ntitle: B\n---\nb")},
+		"posts/c.md": &fstest.MapFile{Data: []byte("---\ntitle: C\n---\nc")},
+	}
+	if err := LoadItems[Post](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	items, err := GetItems[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get items: %v", err)
+	}
+	slices.SortFunc(items, func(a, b ContentItem[Post]) int { return strings.Compare(b.Slug, a.Slug) })
+
+	for _, slug := range []string{"a", "b", "c"} {
+		item, err := GetItem[Post](slug)
+		if err != nil || item.Slug != slug {
+			t.Errorf("Expected item %q after sorting GetItems, got %q (%v)", slug, item.Slug, err)
+		}
+	}
+}
+
+func TestReloadKeepsPreviousItemsOnError(t *testing.T) {
+	fsys := setupTestFS()
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	before, err := GetItems[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get items: %v", err)
+	}
+
+	broken := fstest.MapFS{
+		"posts/broken.md": &fstest.MapFile{Data: []byte("---\ntitle: [unterminated\n---\nbody")},
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := GetItems[Post](); err != nil {
+				t.Errorf("Items unavailable during reload: %v", err)
+			}
+		}()
+	}
+
+	err = LoadItems[Post](broken, "posts")
+	wg.Wait()
+	if err == nil {
+		t.Fatal("Expected error when loading broken frontmatter")
+	}
+
+	after, err := GetItems[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get items after failed reload: %v", err)
+	}
+
+	if len(after) != len(before) {
+		t.Errorf("Expected %d items after failed reload, got %d", len(before), len(after))
+	}
+}
+
+// setWatchInterval sets watchInterval for the duration of the test.
+func setWatchInterval(t *testing.T, d time.Duration) {
+	old := watchInterval
+	watchInterval = d
+	t.Cleanup(func() { watchInterval = old })
+}
+
+func TestWatchReloadsChangedContent(t *testing.T) {
+	setWatchInterval(t, 10*time.Millisecond)
+	t.Cleanup(stopWatching[Post])
+
+	dir := t.TempDir()
+	postPath := filepath.Join(dir, "posts", "watched.md")
+	if err := os.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(postPath, []byte("---\ntitle: Before\n---\nbody"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LoadItems[Post](fstest.MapFS{}, "posts", Watch(dir))
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	if err := os.WriteFile(postPath, []byte("---\ntitle: After the change\n---\nbody"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		item, err := GetItem[Post]("watched")
+		if err != nil {
+			t.Fatalf("Failed to get item: %v", err)
+		}
+		if item.Meta.Title == "After the change" {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatal("Expected watched content to be reloaded")
+}
+
+func TestStopWatchingWaitsForReload(t *testing.T) {
+	setWatchInterval(t, 10*time.Millisecond)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("before"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	var once sync.Once
+	var finished atomic.Bool
+	load := func(fs.FS, string, loadConfig) error {
+		once.Do(func() { close(started) })
+		time.Sleep(50 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}
+	watch[Post](os.DirFS(dir), ".", loadConfig{watchDir: dir}, load)
+
+	if err := os.WriteFile(filepath.Join(dir, "a.md"), []byte("after the change"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		stopWatching[Post]()
+		t.Fatal("Expected a reload")
+	}
+
+	stopWatching[Post]()
+	if !finished.Load() {
+		t.Error("Expected stopWatching to wait for the reload in progress")
+	}
+}
+
+func TestMarkdownPipelineOptions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/options.md": &fstest.MapFile{Data: []byte(`---
+title: Options
+---
+Term
+: Definition
+
+~~struck~~
+
+` + "```go\nfunc main() {}\n```")},
+	}
+
+	err := LoadItems[Post](fsys, "posts",
+		ReplaceExtensions(extension.DefinitionList),
+		HighlightStyle("monokai"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	item, err := GetItem[Post]("options")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if !strings.Contains(item.HTML, "<dl>") {
+		t.Errorf("Expected definition list in HTML: %s", item.HTML)
+	}
+
+	if strings.Contains(item.HTML, "<del>") {
+		t.Errorf("Expected default extensions to be replaced: %s", item.HTML)
+	}
+
+	if !strings.Contains(item.HTML, `class="chroma"`) {
+		t.Errorf("Expected highlighted code block in HTML: %s", item.HTML)
+	}
+}
+
+func TestHighlightCSS(t *testing.T) {
+	css, err := HighlightCSS("monokai")
+	if err != nil {
+		t.Fatalf("Failed to get highlight CSS: %v", err)
+	}
+
+	// monokai's background colour
+	if !strings.Contains(css, "#272822") {
+		t.Errorf("Expected monokai colours in CSS: %s", css)
+	}
+
+	if _, err := HighlightCSS("no-such-style"); err == nil {
+		t.Error("Expected error for unknown style")
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/toc.md": &fstest.MapFile{Data: []byte(`---
+title: TOC
+---
+# Guide
+
+## Install *it*
+
+### On Linux
+
+## Usage
+
+## Usage
+
+# Café Déjà Vu`)},
+	}
+
+	err := LoadItems[Post](fsys, "posts", HeadingAnchors())
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	item, err := GetItem[Post]("toc")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if len(item.TOC) != 2 {
+		t.Fatalf("Expected 2 top-level headings, got %+v", item.TOC)
+	}
+
+	guide := item.TOC[0]
+	if guide.ID != "guide" || guide.Level != 1 || len(guide.Children) != 3 {
+		t.Errorf("Unexpected top-level heading: %+v", guide)
+	}
+
+	install := guide.Children[0]
+	if install.Text != "Install it" || install.ID != "install-it" {
+		t.Errorf("Unexpected heading: %+v", install)
+	}
+
+	if len(install.Children) != 1 || install.Children[0].ID != "on-linux" {
+		t.Errorf("Expected nested heading 'on-linux', got %+v", install.Children)
+	}
+
+	if guide.Children[1].ID != "usage" || guide.Children[2].ID != "usage-1" {
+		t.Errorf("Expected deduplicated ids, got %q and %q", guide.Children[1].ID, guide.Children[2].ID)
+	}
+
+	if item.TOC[1].ID != "cafe-deja-vu" {
+		t.Errorf("Expected accents to be stripped from id, got %q", item.TOC[1].ID)
+	}
+
+	if !strings.Contains(item.HTML, `<h2 id="usage-1">Usage<a href="#usage-1" class="heading-anchor">#</a></h2>`) {
+		t.Errorf("Expected heading anchor in HTML: %s", item.HTML)
+	}
+}
+
+func TestHeadingAnchorsNotInText(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/anchors.md": &fstest.MapFile{Data: []byte("# One\n\n## Two\n\nword")},
+	}
+
+	err := LoadItems[Post](fsys, "posts", HeadingAnchors())
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	item, err := GetItem[Post]("anchors")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if item.Summary != "One Two word" {
+		t.Errorf("Expected anchors to be left out of the summary, got %q", item.Summary)
+	}
+
+	results, err := Search[Post]("word")
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 1 || strings.Contains(results[0].Snippet, "#") {
+		t.Errorf("Expected a snippet without anchors, got %+v", results)
+	}
+}
+
+func TestSummaries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/more.md": &fstest.MapFile{Data: []byte(`---
+title: More
+---
+The *intro* paragraph.
+
+Still the intro.
+
+<!--more-->
+
+The rest of the post.`)},
+		"posts/described.md": &fstest.MapFile{Data: []byte(`---
+title: Described
+description: Set in <frontmatter>
+---
+First paragraph.
+
+Second paragraph.`)},
+		"posts/long.md": &fstest.MapFile{Data: []byte("---\ntitle: Long\n---\n" + strings.Repeat("word ", 450))},
+	}
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	more, err := GetItem[Post]("more")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if more.Excerpt != "<p>The <em>intro</em> paragraph.</p>\n<p>Still the intro.</p>\n" {
+		t.Errorf("Unexpected excerpt: %q", more.Excerpt)
+	}
+	if more.Summary != "The intro paragraph. Still the intro." {
+		t.Errorf("Unexpected summary: %q", more.Summary)
+	}
+	if more.WordCount != 11 {
+		t.Errorf("Expected 11 words, got %d", more.WordCount)
+	}
+	if more.ReadingTime != time.Minute {
+		t.Errorf("Expected 1 minute reading time, got %v", more.ReadingTime)
+	}
+
+	described, err := GetItem[Post]("described")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if described.Summary != "Set in <frontmatter>" {
+		t.Errorf("Expected description as summary, got %q", described.Summary)
+	}
+	if described.Excerpt != "<p>Set in &lt;frontmatter&gt;</p>" {
+		t.Errorf("Expected description as excerpt, got %q", described.Excerpt)
+	}
+
+	long, err := GetItem[Post]("long")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	if long.WordCount != 450 || long.ReadingTime != 3*time.Minute {
+		t.Errorf("Expected 450 words and 3 minutes, got %d and %v", long.WordCount, long.ReadingTime)
+	}
+	if !strings.HasSuffix(long.Summary, "…") || len(strings.Fields(long.Summary)) != summaryWords {
+		t.Errorf("Expected truncated summary, got %q", long.Summary)
+	}
+}
+
+func TestDraftsAndScheduledItems(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	fsys := fstest.MapFS{
+		"posts/published.md": &fstest.MapFile{Data: []byte("---\ntitle: Published\npublishDate: 2020-01-01\n---\nSee [[scheduled]].")},
+		"posts/draft.md":     &fstest.MapFile{Data: []byte("---\ntitle: Draft\ndraft: true\n---\nbody")},
+		"posts/scheduled.md": &fstest.MapFile{Data: []byte("---\ntitle: Scheduled\npublishDate: " + future + "\n---\nSee [[published]].")},
+	}
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	items, err := GetItems[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get items: %v", err)
+	}
+
+	if len(items) != 1 || items[0].Slug != "published" {
+		t.Fatalf("Expected only the published item, got %+v", items)
+	}
+	if len(items[0].Links) != 0 || len(items[0].Backlinks) != 0 {
+		t.Errorf("Expected links to the scheduled item to be hidden, got %v and %v", items[0].Links, items[0].Backlinks)
+	}
+	if published, _ := GetItem[Post]("published"); len(published.Backlinks) != 0 {
+		t.Errorf("Expected backlinks from the scheduled item to be hidden, got %v", published.Backlinks)
+	}
+
+	if _, err := GetItem[Post]("scheduled"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected scheduled item to be hidden, got %v", err)
+	}
+
+	c, err := getCollection[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get collection: %v", err)
+	}
+
+	later := c.visible(time.Now().Add(2 * time.Hour))
+	if len(later) != 2 {
+		t.Errorf("Expected scheduled item to appear once its publishDate passes, got %d items", len(later))
+	}
+	for _, item := range later {
+		if len(item.Backlinks) != 1 {
+			t.Errorf("Expected %s to have its backlink once published, got %v", item.Slug, item.Backlinks)
+		}
+	}
+
+	err = LoadItems[Post](fsys, "posts", IncludeDrafts())
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	items, err = GetItems[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get items: %v", err)
+	}
+
+	if len(items) != 3 {
+		t.Errorf("Expected drafts and scheduled items to be included, got %d items", len(items))
+	}
+}
+
+type StatusPost struct {
+	Title string `yaml:"title"`
+	Draft string `yaml:"draft"`
+}
+
+func TestDraftDeclaredByType(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\ntitle: A\ndraft: pending review\n---\nbody")},
+	}
+
+	if err := LoadItems[StatusPost](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	item, err := GetItem[StatusPost]("a")
+	if err != nil {
+		t.Fatalf("Expected a non-boolean draft key not to hide the item, got %v", err)
+	}
+	if item.Draft || item.Meta.Draft != "pending review" {
+		t.Errorf("Unexpected draft: %v, %q", item.Draft, item.Meta.Draft)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\ntitle: Alpha\ndate: 2024-03-01\n---\nbody")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\ntitle: Bravo\ndate: 2024-01-01\n---\nbody")},
+		"posts/c.md": &fstest.MapFile{Data: []byte("---\ntitle: Charlie\ndate: 2024-02-01\n---\nbody")},
+		"posts/d.md": &fstest.MapFile{Data: []byte("---\ntitle: Delta\ndate: 2024-02-01\n---\nbody")},
+	}
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	titles := func(items []ContentItem[Post]) string {
+		var titles []string
+		for _, item := range items {
+			titles = append(titles, item.Meta.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	items, err := Query[Post]().SortBy("date", true).SortBy("Title", false).Items()
+	if err != nil {
+		t.Fatalf("Failed to run query: %v", err)
+	}
+	if got := titles(items); got != "Alpha,Charlie,Delta,Bravo" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+
+	items, err = Query[Post]().
+		Where(func(item ContentItem[Post]) bool { return item.Meta.Title != "Alpha" }).
+		SortBy("Slug", false).
+		Offset(1).
+		Limit(1).
+		Items()
+	if err != nil {
+		t.Fatalf("Failed to run query: %v", err)
+	}
+	if got := titles(items); got != "Charlie" {
+		t.Errorf("Unexpected page: %s", got)
+	}
+
+	count, err := Query[Post]().Where(func(item ContentItem[Post]) bool { return item.Meta.Date == "2024-02-01" }).Count()
+	if err != nil || count != 2 {
+		t.Errorf("Expected 2 matches, got %d (%v)", count, err)
+	}
+
+	_, err = Query[Post]().Where(func(ContentItem[Post]) bool { return false }).First()
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := Query[Post]().SortBy("nope", false).Items(); err == nil {
+		t.Error("Expected error when sorting by an unknown field")
+	}
+	if _, err := Query[Post]().SortBy("TOC", false).Items(); err == nil {
+		t.Error("Expected error when sorting by an unsortable field")
+	}
+}
+
+type TaggedPost struct {
+	Title    string   `yaml:"title"`
+	Tags     []string `yaml:"tags" ccf:"taxonomy"`
+	Category string   `yaml:"category" ccf:"taxonomy"`
+}
+
+func TestTaxonomies(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md":     &fstest.MapFile{Data: []byte("---\ntitle: A\ntags: [Go, web]\ncategory: Tutorials\n---\nbody")},
+		"posts/b.md":     &fstest.MapFile{Data: []byte("---\ntitle: B\ntags: [go]\n---\nbody")},
+		"posts/c.md":     &fstest.MapFile{Data: []byte("---\ntitle: C\ntags: [web, Go]\n---\nbody")},
+		"posts/draft.md": &fstest.MapFile{Data: []byte("---\ntitle: Draft\ndraft: true\ntags: [go]\n---\nbody")},
+	}
+
+	err := LoadItems[TaggedPost](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	terms, err := Terms[TaggedPost]("tags")
+	if err != nil {
+		t.Fatalf("Failed to get terms: %v", err)
+	}
+
+	expected := []Term{{Name: "Go", Slug: "go", Count: 3}, {Name: "web", Slug: "web", Count: 2}}
+	if len(terms) != len(expected) || terms[0] != expected[0] || terms[1] != expected[1] {
+		t.Errorf("Expected terms %+v, got %+v", expected, terms)
+	}
+
+	items, err := ByTerm[TaggedPost]("tags", "GO")
+	if err != nil {
+		t.Fatalf("Failed to get items by term: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("Expected 3 items tagged go, got %d", len(items))
+	}
+
+	items, err = ByTerm[TaggedPost]("category", "tutorials")
+	if err != nil {
+		t.Fatalf("Failed to get items by term: %v", err)
+	}
+	if len(items) != 1 || items[0].Meta.Title != "A" {
+		t.Errorf("Expected only A in tutorials, got %+v", items)
+	}
+
+	if _, err := Terms[TaggedPost]("title"); err == nil {
+		t.Error("Expected error for a field that is not a taxonomy")
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7}
+
+	tests := []struct {
+		page, perPage int
+		expected      Page[int]
+	}{
+		{1, 3, Page[int]{Items: []int{1, 2, 3}, Page: 1, PerPage: 3, Total: 7, PageCount: 3, Next: 2}},
+		{2, 3, Page[int]{Items: []int{4, 5, 6}, Page: 2, PerPage: 3, Total: 7, PageCount: 3, Prev: 1, Next: 3}},
+		{3, 3, Page[int]{Items: []int{7}, Page: 3, PerPage: 3, Total: 7, PageCount: 3, Prev: 2}},
+		{5, 3, Page[int]{Page: 5, PerPage: 3, Total: 7, PageCount: 3, Prev: 3}},
+		{0, 0, Page[int]{Items: items, Page: 1, PerPage: 7, Total: 7, PageCount: 1}},
+	}
+
+	for _, tt := range tests {
+		got := Paginate(items, tt.page, tt.perPage)
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("Paginate(items, %d, %d) = %+v, expected %+v", tt.page, tt.perPage, got, tt.expected)
+		}
+	}
+
+	empty := Paginate([]int{}, 1, 10)
+	if empty.PageCount != 1 || empty.Next != 0 || len(empty.Items) != 0 {
+		t.Errorf("Expected a single empty page, got %+v", empty)
+	}
+}
+
+func TestBacklinks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"notes/index.md":          &fstest.MapFile{Data: []byte("---\ntitle: Home\n---\nSee [[Go Tips]] and [[projects/ccf|the framework]] and [[go-tips#setup]].")},
+		"notes/go-tips.md":        &fstest.MapFile{Data: []byte("---\ntitle: Go Tips\n---\nBack [[index]]. Also [[missing]] and ![[photo.png]].")},
+		"notes/projects/ccf.md":   &fstest.MapFile{Data: []byte("---\ntitle: CCF\n---\nWritten in go, see [[go-tips]].")},
+		"notes/projects/draft.md": &fstest.MapFile{Data: []byte("---\ntitle: Draft\ndraft: true\n---\n[[ccf]]")},
+	}
+
+	err := LoadItems[Post](fsys, "notes")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	home, err := GetItem[Post]("index")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if strings.Join(home.Links, ",") != "go-tips,projects/ccf" {
+		t.Errorf("Unexpected links: %v", home.Links)
+	}
+
+	tips, err := GetItem[Post]("go-tips")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if strings.Join(tips.Backlinks, ",") != "index,projects/ccf" {
+		t.Errorf("Unexpected backlinks: %v", tips.Backlinks)
+	}
+
+	backlinks, err := Backlinks[Post]("projects/ccf")
+	if err != nil {
+		t.Fatalf("Failed to get backlinks: %v", err)
+	}
+	if len(backlinks) != 1 || backlinks[0].Meta.Title != "Home" {
+		t.Errorf("Expected only Home to link to ccf, got %+v", backlinks)
+	}
+
+	if !strings.Contains(home.HTML, `<a href="/notes/projects/ccf">the framework</a>`) {
+		t.Errorf("Expected labelled link to resolve to its slug: %s", home.HTML)
+	}
+	if !strings.Contains(home.HTML, `<a href="/notes/go-tips#setup">`) {
+		t.Errorf("Expected fragment link to resolve to a heading: %s", home.HTML)
+	}
+	if !strings.Contains(tips.HTML, `Also missing and`) {
+		t.Errorf("Expected broken link to render as its label: %s", tips.HTML)
+	}
+
+	broken, err := BrokenLinks[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get broken links: %v", err)
+	}
+	if len(broken) != 1 || broken[0] != (BrokenLink{Path: "notes/go-tips.md", Target: "missing"}) {
+		t.Errorf("Unexpected broken links: %+v", broken)
+	}
+
+	graph, err := Graph[Post]()
+	if err != nil {
+		t.Fatalf("Failed to get graph: %v", err)
+	}
+	if len(graph.Nodes) != 3 || len(graph.Links) != 4 {
+		t.Errorf("Expected 3 nodes and 4 links, got %+v", graph)
+	}
+}
+
+func TestWikilinkResolution(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/first.md":  &fstest.MapFile{Data: []byte("---\ntitle: First\naliases: [Intro]\n---\nbody")},
+		"posts/second.md": &fstest.MapFile{Data: []byte("---\ntitle: Second\n---\nSee [[intro]] and [[nowhere]].")},
+	}
+
+	err := LoadItems[Post](fsys, "posts", LinkPrefix("/blog/"))
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	second, err := GetItem[Post]("second")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if !strings.Contains(second.HTML, `<a href="/blog/first">intro</a>`) {
+		t.Errorf("Expected alias to resolve under the link prefix: %s", second.HTML)
+	}
+
+	err = LoadItems[Post](fsys, "posts", Strict())
+	var brokenErr *BrokenLinksError
+	if !errors.As(err, &brokenErr) || len(brokenErr.Links) != 1 || brokenErr.Links[0].Target != "nowhere" {
+		t.Errorf("Expected BrokenLinksError for [[nowhere]] in strict mode, got %v", err)
+	}
+
+	resolve := func(target string) string { return "/other/" + target }
+	if err := LoadItems[Post](fsys, "posts", ResolveLink(resolve), Strict()); err != nil {
+		t.Fatalf("Expected no broken links with a custom resolver, got %v", err)
+	}
+	if broken, _ := BrokenLinks[Post](); len(broken) != 0 {
+		t.Errorf("Expected no broken links with a custom resolver, got %v", broken)
+	}
+	second, _ = GetItem[Post]("second")
+	if !strings.Contains(second.HTML, `<a href="/other/nowhere">nowhere</a>`) {
+		t.Errorf("Expected custom resolver to be used: %s", second.HTML)
+	}
+}
+
+func TestAliasShapes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\naliases: alpha\n---\nbody")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\naliases: {not: a list}\n---\nSee [[alpha]].")},
+	}
+
+	if err := LoadItems[Post](fsys, "posts", Strict()); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	b, err := GetItem[Post]("b")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if len(b.Links) != 1 || b.Links[0] != "a" {
+		t.Errorf("Expected a single string alias to resolve, got %v", b.Links)
+	}
+}
+
+type ValidatedPost struct {
+	Title string `yaml:"title" ccf:"required"`
+	Date  string `yaml:"date"`
+}
+
+func (p ValidatedPost) Validate() error {
+	if p.Date != "" && !strings.HasPrefix(p.Date, "20") {
+		return fmt.Errorf("date %q is not in this century", p.Date)
+	}
+	return nil
+}
+
+func TestFrontmatterValidation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/good.md":     &fstest.MapFile{Data: []byte("---\ntitle: Good\ndraft: false\n---\nbody")},
+		"posts/typo.md":     &fstest.MapFile{Data: []byte("---\ntitle: Typo\ndaet: 2024-01-01\n---\nbody")},
+		"posts/cased.md":    &fstest.MapFile{Data: []byte("---\ntitle: Cased\nDate: 2024-01-01\n---\nbody")},
+		"posts/untitled.md": &fstest.MapFile{Data: []byte("---\ndate: 1999-01-01\n---\nbody")},
+		"posts/listed.md":   &fstest.MapFile{Data: []byte("\n---\n# Decode errors count lines from the file\ndate: 2024-01-01\ntitle: [a, b]\n---\nbody")},
+	}
+
+	err := LoadItems[ValidatedPost](fsys, "posts")
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{
+		`posts/untitled.md: missing required key "title"`,
+		`posts/untitled.md: date "1999-01-01" is not in this century`,
+		"posts/listed.md:5: cannot unmarshal !!seq into string",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "daet") {
+		t.Errorf("Expected unknown keys to be allowed outside strict mode: %v", err)
+	}
+
+	err = LoadItems[ValidatedPost](fsys, "posts", RejectUnknownKeys())
+	var fmErr *FrontmatterError
+	if !errors.As(err, &fmErr) {
+		t.Fatalf("Expected FrontmatterError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `posts/typo.md:3: unknown key "daet"`) {
+		t.Errorf("Expected unknown key error with its line, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `posts/cased.md:3: unknown key "Date"`) {
+		t.Errorf("Expected keys to be matched case-sensitively, got %v", err)
+	}
+
+	err = LoadItems[ValidatedPost](fsys, "posts", Strict())
+	if err == nil || !strings.Contains(err.Error(), `unknown key "daet"`) {
+		t.Errorf("Expected Strict to reject unknown keys, got %v", err)
+	}
+}
+
+type DatedPost struct {
+	Title string `yaml:"title"`
+	Date  Date   `yaml:"date"`
+}
+
+func TestDates(t *testing.T) {
+	modTime := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"posts/plain.md":               &fstest.MapFile{Data: []byte("---\ndate: 2024-01-02\n---\nbody")},
+		"posts/zoned.md":               &fstest.MapFile{Data: []byte("---\ndate: 2024-01-02 10:30 Europe/Paris\n---\nbody")},
+		"posts/offset.md":              &fstest.MapFile{Data: []byte("---\ndate: 2024-01-02T10:30:00+02:00\n---\nbody")},
+		"posts/written.md":             &fstest.MapFile{Data: []byte("---\ndate: January 2, 2024\n---\nbody")},
+		"posts/toml.md":                &fstest.MapFile{Data: []byte("+++\ndate = 2024-01-02T10:30:00Z\n+++\nbody")},
+		"posts/2023-05-06-prefixed.md": &fstest.MapFile{Data: []byte("---\ntitle: Prefixed\n---\nbody")},
+		"posts/undated.md":             &fstest.MapFile{Data: []byte("---\ntitle: Undated\n---\nbody"), ModTime: modTime},
+	}
+
+	err := LoadItems[DatedPost](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	tests := map[string]time.Time{
+		"plain":               time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"zoned":               time.Date(2024, 1, 2, 10, 30, 0, 0, paris),
+		"offset":              time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC),
+		"written":             time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"toml":                time.Date(2024, 1, 2, 10, 30, 0, 0, time.UTC),
+		"2023-05-06-prefixed": time.Date(2023, 5, 6, 0, 0, 0, 0, time.UTC),
+		"undated":             modTime,
+	}
+	for slug, want := range tests {
+		item, err := GetItem[DatedPost](slug)
+		if err != nil {
+			t.Errorf("Failed to get %s: %v", slug, err)
+			continue
+		}
+		if !item.Date.Equal(want) || !item.Meta.Date.Equal(want) {
+			t.Errorf("Expected %s to be dated %v, got %v (meta %v)", slug, want, item.Date, item.Meta.Date)
+		}
+	}
+
+	items, err := Query[DatedPost]().SortBy("date", true).Items()
+	if err != nil {
+		t.Fatalf("Failed to query items: %v", err)
+	}
+	if items[0].Slug != "toml" || items[len(items)-1].Slug != "undated" {
+		t.Errorf("Expected items sorted by date, got %s first and %s last", items[0].Slug, items[len(items)-1].Slug)
+	}
+
+	bad := fstest.MapFS{
+		"posts/bad.md":       &fstest.MapFile{Data: []byte("---\ntitle: Bad\n\ndate: someday\n---\nbody")},
+		"posts/bad-toml.md":  &fstest.MapFile{Data: []byte("+++\ntitle = \"Bad\"\ndate = \"someday\"\n+++\nbody")},
+		"posts/scheduled.md": &fstest.MapFile{Data: []byte("---\ntitle: Scheduled\npublishDate: soon\n---\nbody")},
+	}
+	err = LoadItems[DatedPost](bad, "posts")
+	for _, want := range []string{
+		`posts/bad.md:4: unrecognized date "someday"`,
+		`posts/bad-toml.md:3: unrecognized date "someday"`,
+		`posts/scheduled.md:3: unrecognized date "soon"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q, got %v", want, err)
+		}
+	}
+}
+
+type Author struct {
+	ID    string `yaml:"id" json:"id" toml:"id"`
+	Name  string `yaml:"name" json:"name" toml:"name" ccf:"required"`
+	Posts int    `yaml:"posts" json:"posts" toml:"posts"`
+}
+
+func TestLoadData(t *testing.T) {
+	fsys := fstest.MapFS{
+		"authors/jane.yaml":    &fstest.MapFile{Data: []byte("name: Jane\nposts: 3\n")},
+		"authors/john.json":    &fstest.MapFile{Data: []byte(`{"name": "John", "posts": 1}`)},
+		"authors/ann.toml":     &fstest.MapFile{Data: []byte("name = \"Ann\"\n")},
+		"authors/guests.csv":   &fstest.MapFile{Data: []byte("id,name,posts\nbob,Bob,2\n,Eve,\n")},
+		"authors/staff.yml":    &fstest.MapFile{Data: []byte("- id: amy\n  name: Amy\n")},
+		"authors/ignored.md":   &fstest.MapFile{Data: []byte("# not data")},
+		"authors/more/kim.yml": &fstest.MapFile{Data: []byte("name: Kim\n")},
+	}
+
+	err := LoadData[Author](fsys, "authors")
+	if err != nil {
+		t.Fatalf("Failed to load data: %v", err)
+	}
+
+	want := map[string]Author{
+		"jane":       {Name: "Jane", Posts: 3},
+		"john":       {Name: "John", Posts: 1},
+		"ann":        {Name: "Ann"},
+		"guests/bob": {ID: "bob", Name: "Bob", Posts: 2},
+		"guests/2":   {Name: "Eve"},
+		"staff/amy":  {ID: "amy", Name: "Amy"},
+		"more/kim":   {Name: "Kim"},
+	}
+	items, err := GetItems[Author]()
+	if err != nil {
+		t.Fatalf("Failed to get items: %v", err)
+	}
+	if len(items) != len(want) {
+		t.Errorf("Expected %d items, got %d", len(want), len(items))
+	}
+	for slug, meta := range want {
+		item, err := GetItem[Author](slug)
+		if err != nil {
+			t.Errorf("Failed to get %s: %v", slug, err)
+			continue
+		}
+		if item.Meta != meta {
+			t.Errorf("Expected %s to be %+v, got %+v", slug, meta, item.Meta)
+		}
+	}
+
+	bad := fstest.MapFS{
+		"authors/a.yaml": &fstest.MapFile{Data: []byte("posts: lots\n")},
+		"authors/b.csv":  &fstest.MapFile{Data: []byte("id,name\nb,\n")},
+	}
+	err = LoadData[Author](bad, "authors")
+	if err == nil || !strings.Contains(err.Error(), "authors/a.yaml") || !strings.Contains(err.Error(), `authors/b.csv: missing required key "name"`) {
+		t.Errorf("Expected errors for both files, got %v", err)
+	}
+}
+
+type RefPost struct {
+	Title   string   `yaml:"title"`
+	Author  string   `yaml:"author" ccf:"ref=Author"`
+	Related []string `yaml:"related" ccf:"ref=RefPost"`
+}
+
+func TestCheckRefs(t *testing.T) {
+	authors := fstest.MapFS{
+		"authors/jane.yaml": &fstest.MapFile{Data: []byte("name: Jane\n")},
+	}
+	if err := LoadData[Author](authors, "authors"); err != nil {
+		t.Fatalf("Failed to load authors: %v", err)
+	}
+
+	posts := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\nauthor: jane\nrelated: [b]\n---\nbody")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\ntitle: No author\n---\nbody")},
+	}
+	if err := LoadItems[RefPost](posts, "posts"); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+	if err := CheckRefs(); err != nil {
+		t.Errorf("Expected references to resolve, got %v", err)
+	}
+
+	posts["posts/c.md"] = &fstest.MapFile{Data: []byte("---\nauthor: jne\nrelated: [a, d]\n---\nbody")}
+	if err := LoadItems[RefPost](posts, "posts"); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	err := CheckRefs()
+	var refErr *RefError
+	if !errors.As(err, &refErr) {
+		t.Fatalf("Expected RefError, got %v", err)
+	}
+	for _, want := range []string{
+		`RefPost "c": author "jne" matches no Author`,
+		`RefPost "c": related "d" matches no RefPost`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error %q in %v", want, err)
+		}
+	}
+}
+
+func TestCheckRefsAmbiguousType(t *testing.T) {
+	authors := fstest.MapFS{
+		"authors/jane.yaml": &fstest.MapFile{Data: []byte("name: Jane\n")},
+	}
+	if err := LoadData[Author](authors, "authors"); err != nil {
+		t.Fatalf("Failed to load authors: %v", err)
+	}
+	posts := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\nauthor: jane\n---\nbody")},
+	}
+	if err := LoadItems[RefPost](posts, "posts"); err != nil {
+		t.Fatalf("Failed to load posts: %v", err)
+	}
+
+	// Another type named Author, as if from another package.
+	type Author struct {
+		Name string `yaml:"name"`
+	}
+	other := reflect.TypeFor[Author]()
+	t.Cleanup(func() {
+		storeMu.Lock()
+		delete(store, other)
+		storeMu.Unlock()
+	})
+	if err := LoadData[Author](authors, "authors"); err != nil {
+		t.Fatalf("Failed to load authors: %v", err)
+	}
+
+	err := CheckRefs()
+	if err == nil || !strings.Contains(err.Error(), "RefPost references Author in author, which names more than one loaded type") {
+		t.Errorf("Expected an error for the ambiguous type name, got %v", err)
+	}
+}
+
+func TestSeries(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/intro.md":    &fstest.MapFile{Data: []byte("---\nseries: Go Basics\nseriesPart: 1\n---\nbody")},
+		"posts/types.md":    &fstest.MapFile{Data: []byte("---\nseries: Go Basics\nseriesPart: 2\n---\nbody")},
+		"posts/extra.md":    &fstest.MapFile{Data: []byte("---\nseries: go basics\n---\nbody")},
+		"posts/draft.md":    &fstest.MapFile{Data: []byte("---\nseries: Go Basics\nseriesPart: 3\ndraft: true\n---\nbody")},
+		"posts/unseries.md": &fstest.MapFile{Data: []byte("---\ntitle: Alone\n---\nbody")},
+		"posts/named.md":    &fstest.MapFile{Data: []byte("---\nseries: [not, a, name]\nseriesPart: first\n---\nbody")},
+	}
+
+	err := LoadItems[Post](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	items, err := SeriesOf[Post]("types")
+	if err != nil {
+		t.Fatalf("Failed to get series: %v", err)
+	}
+	var slugs []string
+	for _, item := range items {
+		slugs = append(slugs, item.Slug)
+	}
+	if strings.Join(slugs, ",") != "intro,types,extra" {
+		t.Errorf("Expected series intro,types,extra, got %v", slugs)
+	}
+
+	nav, ok := Neighbors(items, func(item ContentItem[Post]) bool { return item.Slug == "types" })
+	if !ok {
+		t.Fatal("Expected to find types in its series")
+	}
+	if nav.Position() != 2 || nav.Total() != 3 || nav.Prev.Slug != "intro" || nav.Next.Slug != "extra" {
+		t.Errorf("Unexpected nav: part %d of %d", nav.Position(), nav.Total())
+	}
+
+	nav, _ = Neighbors(items, func(item ContentItem[Post]) bool { return item.Slug == "intro" })
+	if nav.Prev != nil {
+		t.Errorf("Expected no previous item for the first part, got %s", nav.Prev.Slug)
+	}
+
+	for _, slug := range []string{"unseries", "named"} {
+		if items, err := SeriesOf[Post](slug); err != nil || items != nil {
+			t.Errorf("Expected no series for %s, got %v, %v", slug, items, err)
+		}
+	}
+	if _, err := SeriesOf[Post]("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+type HookedPost struct {
+	Title    string   `yaml:"title"`
+	Tags     []string `yaml:"tags"`
+	URL      string   `yaml:"-"`
+	Siblings int      `yaml:"-"`
+}
+
+func (p HookedPost) AfterLoad(item *ContentItem[HookedPost]) error {
+	if item.Meta.Title == "" {
+		return errors.New("title is empty")
+	}
+	item.Meta.URL = "https://example.com/blog/" + item.Slug
+	for i, tag := range item.Meta.Tags {
+		item.Meta.Tags[i] = strings.ToLower(tag)
+	}
+	return nil
+}
+
+func (p HookedPost) AfterLoadAll(items []ContentItem[HookedPost]) error {
+	for i := range items {
+		items[i].Meta.Siblings = len(items) - 1
+	}
+	return nil
+}
+
+func TestAfterLoadHooks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\ntitle: A\ntags: [Go]\n---\nbody")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\ntitle: B\n---\nbody")},
+	}
+
+	err := LoadItems[HookedPost](fsys, "posts")
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	a, err := GetItem[HookedPost]("a")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+	if a.Meta.URL != "https://example.com/blog/a" || a.Meta.Tags[0] != "go" {
+		t.Errorf("Expected AfterLoad to set computed fields, got %+v", a.Meta)
+	}
+	if a.Meta.Siblings != 1 {
+		t.Errorf("Expected AfterLoadAll to set Siblings to 1, got %d", a.Meta.Siblings)
+	}
+
+	fsys["posts/c.md"] = &fstest.MapFile{Data: []byte("---\ntags: [x]\n---\nbody")}
+	err = LoadItems[HookedPost](fsys, "posts")
+	if err == nil || !strings.Contains(err.Error(), "posts/c.md: title is empty") {
+		t.Errorf("Expected AfterLoad error for posts/c.md, got %v", err)
+	}
+}
+
+type SluggedPost struct {
+	Title string `yaml:"title"`
+}
+
+func (p SluggedPost) AfterLoad(item *ContentItem[SluggedPost]) error {
+	item.Slug = "same"
+	return nil
+}
+
+func TestAfterLoadSlugChange(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/a.md": &fstest.MapFile{Data: []byte("---\ntitle: A\n---\nbody")},
+		"posts/b.md": &fstest.MapFile{Data: []byte("---\ntitle: B\n---\nbody")},
+	}
+
+	err := LoadItems[SluggedPost](fsys, "posts")
+	if err == nil || !strings.Contains(err.Error(), `posts/a.md: slug changed from "a" to "same"`) {
+		t.Errorf("Expected an error for the changed slug, got %v", err)
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/hello.md":               &fstest.MapFile{Data: []byte("---\ntitle: Héllo, Wörld!\ndate: 2024-01-02\n---\nbody")},
+		"posts/2023-05-06-untitled.md": &fstest.MapFile{Data: []byte("---\ndescription: No title\n---\nbody")},
+		"posts/nested/custom.md":       &fstest.MapFile{Data: []byte("---\ntitle: Custom\nslug: /Mon Chemin/À Part/\n---\nbody")},
+		"posts/cyrillic.md":            &fstest.MapFile{Data: []byte("---\ntitle: Привет мир\ndate: 2024-02-03\n---\nbody")},
+		"posts/numbered.md":            &fstest.MapFile{Data: []byte("---\ntitle: Numbered\nslug: 42\n---\nbody")},
+	}
+
+	err := LoadItems[Post](fsys, "posts", Permalink(":year/:month/:title"))
+	if err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	for _, slug := range []string{"2024/01/hello-world", "2023/05/untitled", "mon-chemin/a-part", "2024/02/привет-мир", "42"} {
+		if _, err := GetItem[Post](slug); err != nil {
+			t.Errorf("Expected item with slug %q: %v", slug, err)
+		}
+	}
+
+	err = LoadItems[Post](fsys, "posts", Permalink(":year/:nope"))
+	if err == nil || !strings.Contains(err.Error(), "unknown permalink token :nope") {
+		t.Errorf("Expected unknown token error, got %v", err)
+	}
+
+	fsys["posts/again.md"] = &fstest.MapFile{Data: []byte("---\ntitle: Hello world\ndate: 2024-01-20\n---\nbody")}
+	err = LoadItems[Post](fsys, "posts", Permalink(":year/:month/:title"))
+	if err == nil || !strings.Contains(err.Error(), `duplicate slug "2024/01/hello-world"`) {
+		t.Errorf("Expected duplicate slug error, got %v", err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/config.md":    &fstest.MapFile{Data: []byte("---\ntitle: Configuring the server\n---\nThe server reads its settings from a file.")},
+		"posts/deploy.md":    &fstest.MapFile{Data: []byte("---\ntitle: Deploying\ndescription: Shipping the server\n---\nCopy the binary, then restart the configured service.")},
+		"posts/unrelated.md": &fstest.MapFile{Data: []byte("---\ntitle: Cooking\n---\nBoil water & salt.")},
+	}
+	if err := LoadItems[Post](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	results, err := Search[Post]("configured")
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 2 || results[0].Item.Slug != "config" {
+		t.Fatalf("Expected the title match first, got %+v", results)
+	}
+	if results[0].Title != "<mark>Configuring</mark> the server" {
+		t.Errorf("Unexpected title: %q", results[0].Title)
+	}
+	if !strings.Contains(results[1].Snippet, "restart the <mark>configured</mark> service.") {
+		t.Errorf("Unexpected snippet: %q", results[1].Snippet)
+	}
+
+	for q, want := range map[string]int{
+		"server settings": 1,
+		"the":             0,
+		"ship":            1,
+		"serv":            2,
+		"cooking server":  0,
+		"water":           1,
+	} {
+		results, err := Search[Post](q)
+		if err != nil || len(results) != want {
+			t.Errorf("Expected %d results for %q, got %d (%v)", want, q, len(results), err)
+		}
+	}
+
+	results, _ = Search[Post]("water")
+	if len(results) == 1 && results[0].Snippet != "Boil <mark>water</mark> &amp; salt." {
+		t.Errorf("Expected escaped snippet, got %q", results[0].Snippet)
+	}
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestResponsiveImages(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/photo.md":       &fstest.MapFile{Data: []byte("---\ntitle: Photo\n---\n![Big](big.png)\n\n![[small.png]]\n\n![Remote](https://example.com/a.png)\n\n![Broken](unreadable.png)")},
+		"posts/big.png":        &fstest.MapFile{Data: encodePNG(t, 1000, 500)},
+		"posts/small.png":      &fstest.MapFile{Data: encodePNG(t, 100, 80)},
+		"posts/unreadable.png": &fstest.MapFile{Data: []byte("not a png")},
+	}
+	opts := ImageOptions{Widths: []int{320, 640}, Sizes: "50vw", CacheDir: t.TempDir()}
+
+	if err := LoadItems[Post](fsys, "posts", ResponsiveImages(opts)); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+	item, err := GetItem[Post]("photo")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(opts.CacheDir, "big-*.png"))
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 cached variants, got %v", files)
+	}
+	w320, w640 := "/_images/"+filepath.Base(files[0]), "/_images/"+filepath.Base(files[1])
+	for _, want := range []string{
+		`<img src="` + w640 + `" alt="Big" height="500" loading="lazy" sizes="50vw" srcset="` + w320 + ` 320w, ` + w640 + ` 640w" width="1000">`,
+		`<img src="/content/posts/small.png" alt="small.png" height="80" loading="lazy" width="100">`,
+		`<img src="https://example.com/a.png" alt="Remote" loading="lazy">`,
+		`<img src="/content/posts/unreadable.png" alt="Broken" loading="lazy">`,
+	} {
+		if !strings.Contains(item.HTML, want) {
+			t.Errorf("Expected %s in HTML: %s", want, item.HTML)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	e.GET(DefaultImagePath+"*", ImageHandler(opts))
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, w320, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected variant to be served, got %d", rec.Code)
+	}
+	config, err := png.DecodeConfig(rec.Body)
+	if err != nil || config.Width != 320 || config.Height != 160 {
+		t.Errorf("Expected a 320x160 variant, got %+v (%v)", config, err)
+	}
+}
+
+func TestImageHook(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/figures.md": &fstest.MapFile{Data: []byte("---\ntitle: Figures\n---\n![A \"cat\"](cat.jpg \"Tom &amp; Jerry\")\n\n![[dog.png]]")},
+	}
+
+	var seen []Image
+	hook := func(img *Image) (templ.Component, error) {
+		seen = append(seen, *img)
+		if img.Embed {
+			img.Attrs["class"] = "embed"
+			return nil, nil
+		}
+		return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+			if _, err := io.WriteString(w, "<figure>"); err != nil {
+				return err
+			}
+			if err := img.Tag().Render(ctx, w); err != nil {
+				return err
+			}
+			_, err := io.WriteString(w, "<figcaption>"+templ.EscapeString(img.Title)+"</figcaption></figure>")
+			return err
+		}), nil
+	}
+
+	if err := LoadItems[Post](fsys, "posts", OnImage(hook)); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+	item, err := GetItem[Post]("figures")
+	if err != nil {
+		t.Fatalf("Failed to get item: %v", err)
+	}
+
+	want := []Image{
+		{Src: "/content/posts/cat.jpg", Destination: "cat.jpg", Alt: `A "cat"`, Title: "Tom & Jerry", Path: "posts/figures.md"},
+		{Src: "/content/posts/dog.png", Destination: "dog.png", Alt: "dog.png", Path: "posts/figures.md", Embed: true},
+	}
+	// The first paragraph is rendered again for the excerpt.
+	if len(seen) != len(want)+1 {
+		t.Fatalf("Expected %d images, got %+v", len(want)+1, seen)
+	}
+	for i, img := range seen[:len(want)] {
+		if img.Src != want[i].Src || img.Destination != want[i].Destination || img.Alt != want[i].Alt ||
+			img.Title != want[i].Title || img.Path != want[i].Path || img.Embed != want[i].Embed {
+			t.Errorf("Expected image %+v, got %+v", want[i], img)
+		}
+	}
+
+	for _, html := range []string{
+		`<figure><img src="/content/posts/cat.jpg" alt="A &#34;cat&#34;" title="Tom &amp; Jerry"><figcaption>Tom &amp; Jerry</figcaption></figure>`,
+		`<img src="/content/posts/dog.png" alt="dog.png" class="embed">`,
+	} {
+		if !strings.Contains(item.HTML, html) {
+			t.Errorf("Expected %s in HTML: %s", html, item.HTML)
+		}
+	}
+
+	failing := func(img *Image) (templ.Component, error) { return nil, errors.New("no cats") }
+	err = LoadItems[Post](fsys, "posts", OnImage(failing))
+	if err == nil || !strings.Contains(err.Error(), "image hook failed for cat.jpg: no cats") {
+		t.Errorf("Expected hook error, got %v", err)
+	}
+}
+
 // func TestLoadItemsNonexistentDirectory(t *testing.T) {
 // 	fsys := fstest.MapFS{}
 
@@ -0,0 +1,249 @@
+package content
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// dataFormats split a data file into records, keyed by file extension. Each
+// record is returned as a function decoding it into a value, and list
+// reports whether the file holds a list of records rather than a single one.
+var dataFormats = map[string]func(data []byte) (records []func(v any) error, list bool, err error){
+	".yaml": yamlRecords,
+	".yml":  yamlRecords,
+	".json": jsonRecords,
+	".toml": tomlRecords,
+	".csv":  csvRecords,
+}
+
+// LoadData loads a collection of structured data, such as authors or menus,
+// from the YAML, JSON, TOML and CSV files in dirName. The items have no body
+// and are read with the same functions as markdown content, e.g. GetItems
+// and GetItem.
+//
+// A file holding a single object is one item, slugged by its path like
+// markdown files. A YAML or JSON list and the rows of a CSV file (with a
+// header row) are one item each, slugged by the file's slug followed by
+// the record's slug or id field, or else its 1-based position.
+//
+// Records are validated like frontmatter, and Strict, RejectUnknownKeys and
+// Watch apply.
+// Markdown options are ignored.
+func LoadData[T any](fsys fs.FS, dirName string, opts ...LoadOpt) error {
+	var cfg loadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	stopWatching[T]()
+
+	if cfg.watchDir != "" {
+		fsys = os.DirFS(cfg.watchDir)
+	}
+
+	if err := loadData[T](fsys, dirName, cfg); err != nil {
+		return err
+	}
+
+	if cfg.watchDir != "" {
+		watch[T](fsys, dirName, cfg, loadData[T])
+	}
+
+	return nil
+}
+
+func loadData[T any](fsys fs.FS, dirName string, cfg loadConfig) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	var items []ContentItem[T]
+	var paths []string
+	// errs collects the decoding and validation errors of every file.
+	var errs []error
+
+	slog.Info("Loading data", "type", t, "dir", dirName)
+	err := fs.WalkDir(fsys, dirName, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d == nil {
+				return fmt.Errorf("dir is missing: %w", err)
+			}
+			return fmt.Errorf("failed to walk directory: %w", err)
+		}
+
+		records, ok := dataFormats[strings.ToLower(filepath.Ext(d.Name()))]
+		if d.IsDir() || !ok {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read data file %s: %w", path, err)
+		}
+
+		decoders, list, err := records(data)
+		if err != nil {
+			errs = append(errs, &FrontmatterError{Path: path, Err: err})
+			return nil
+		}
+
+		slug := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(path, dirName+"/"), filepath.Ext(path)), "/index")
+		for i, decode := range decoders {
+			meta := reflect.New(t).Interface()
+			var keys map[string]any
+			if err := decode(meta); err != nil {
+				errs = append(errs, decodeErrors(path, data, 0, t, err)...)
+				continue
+			}
+			if err := decode(&keys); err != nil {
+				errs = append(errs, &FrontmatterError{Path: path, Err: err})
+				continue
+			}
+
+			if invalid := validateFrontmatter(path, data, meta, keys, cfg.strict || cfg.rejectUnknown); len(invalid) > 0 {
+				errs = append(errs, invalid...)
+				continue
+			}
+
+			item := ContentItem[T]{Slug: slug}
+			if list {
+				item.Slug = slug + "/" + recordSlug(keys, i)
+			}
+			item.Date = itemDate(reflect.ValueOf(meta), item.Slug, d)
+			item.Meta = reflect.ValueOf(meta).Elem().Interface().(T)
+
+			items = append(items, item)
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to load data items: %w", err)
+	}
+
+	slugs := make([]string, len(items))
+	for i, item := range items {
+		slugs[i] = item.Slug
+	}
+	errs = append(errs, duplicateSlugs(slugs, paths)...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load data items: %w", errors.Join(errs...))
+	}
+
+	if err := runAfterLoad(items, paths); err != nil {
+		return err
+	}
+
+	c := newCollection(items, false)
+	c.search = indexSearch(items, nil)
+
+	storeMu.Lock()
+	store[t] = c
+	storeMu.Unlock()
+
+	return nil
+}
+
+// recordSlug returns the slug of the i-th record of a file.
+func recordSlug(keys map[string]any, i int) string {
+	for _, key := range []string{"slug", "id"} {
+		if v, ok := keys[key]; ok {
+			if slug := slugify(fmt.Sprint(v)); slug != "" {
+				return slug
+			}
+		}
+	}
+
+	return strconv.Itoa(i + 1)
+}
+
+func yamlRecords(data []byte) ([]func(v any) error, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, false, nil
+	}
+
+	root := doc.Content[0]
+	list := root.Kind == yaml.SequenceNode
+	nodes := []*yaml.Node{root}
+	if list {
+		nodes = root.Content
+	}
+
+	decoders := make([]func(v any) error, len(nodes))
+	for i, node := range nodes {
+		decoders[i] = node.Decode
+	}
+	return decoders, list, nil
+}
+
+func jsonRecords(data []byte) ([]func(v any) error, bool, error) {
+	raws := []json.RawMessage{data}
+	list := bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+	if list {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, false, err
+		}
+	}
+
+	decoders := make([]func(v any) error, len(raws))
+	for i, raw := range raws {
+		decoders[i] = func(v any) error {
+			return json.Unmarshal(raw, v)
+		}
+	}
+	return decoders, list, nil
+}
+
+func tomlRecords(data []byte) ([]func(v any) error, bool, error) {
+	return []func(v any) error{
+		func(v any) error {
+			return toml.Unmarshal(data, v)
+		},
+	}, false, nil
+}
+
+// csvRecords decodes each row after the header as a YAML mapping of plain
+// scalars, so cells convert to the field types the way YAML values would.
+func csvRecords(data []byte) ([]func(v any) error, bool, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, true, err
+	}
+	if len(rows) == 0 {
+		return nil, true, nil
+	}
+
+	header := rows[0]
+	decoders := make([]func(v any) error, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for i, cell := range row {
+			if i >= len(header) || cell == "" {
+				continue
+			}
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: header[i]},
+				&yaml.Node{Kind: yaml.ScalarNode, Value: cell},
+			)
+		}
+		decoders = append(decoders, node.Decode)
+	}
+	return decoders, true, nil
+}
@@ -0,0 +1,175 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Date is a frontmatter date that accepts the layouts authors commonly
+// write, such as 2024-01-02, 2024-01-02 15:04, RFC 3339 or "January 2, 2006",
+// optionally followed by an IANA zone name like Europe/Paris. Dates without
+// a zone are in UTC.
+//
+// If the date field of an item is a zero Date, LoadItems fills it from a
+// 2024-01-02- file name prefix, or else the file's modification time.
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalText(text []byte) error {
+	t, err := parseDate(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Time = t
+	return nil
+}
+
+func (d *Date) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: cannot decode %s into a date", node.Line, node.ShortTag())}}
+	}
+	if node.ShortTag() == "!!null" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	if err := d.UnmarshalText([]byte(node.Value)); err != nil {
+		// A TypeError lets yaml.v3 carry on and report the other errors of
+		// the document too.
+		return &yaml.TypeError{Errors: []string{fmt.Sprintf("line %d: %v", node.Line, err)}}
+	}
+	return nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("cannot decode %s into a date", data)
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// UnmarshalTOML accepts both TOML datetimes and strings.
+func (d *Date) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case time.Time:
+		d.Time = v
+		return nil
+	case string:
+		return d.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("cannot decode %v into a date", v)
+	}
+}
+
+// dateLayouts are the date formats accepted in frontmatter.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// parseDate parses a frontmatter date. Dates without a zone are in UTC, or
+// in the IANA zone named after the date.
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	loc := time.UTC
+	if i := strings.LastIndexByte(s, ' '); i > 0 {
+		if zone := s[i+1:]; zone == "UTC" || strings.Contains(zone, "/") {
+			if l, err := time.LoadLocation(zone); err == nil {
+				s, loc = s[:i], l
+			}
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, &dateError{value: s}
+}
+
+// dateError is a date parseDate does not recognize.
+type dateError struct {
+	value string
+}
+
+func (e *dateError) Error() string {
+	return fmt.Sprintf("unrecognized date %q", e.value)
+}
+
+// fileDatePrefix matches a date at the start of a file name, e.g. 2024-01-02-title.md.
+var fileDatePrefix = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-`)
+
+// itemDate returns the date of an item: its date frontmatter field, else the
+// date prefix of its file (or, for index files, directory) name, else the
+// file's modification time. A zero Date field is set to the result.
+func itemDate(meta reflect.Value, name string, d fs.DirEntry) time.Time {
+	field, ok := metaField(meta, "date")
+	if ok {
+		switch v := field.Interface().(type) {
+		case Date:
+			if !v.IsZero() {
+				return v.Time
+			}
+		case time.Time:
+			if !v.IsZero() {
+				return v
+			}
+		case string:
+			if t, err := parseDate(v); err == nil {
+				return t
+			}
+		}
+	}
+
+	var date time.Time
+	if m := fileDatePrefix.FindStringSubmatch(path.Base(name)); m != nil {
+		date, _ = time.Parse(time.DateOnly, m[1])
+	}
+	if date.IsZero() {
+		if info, err := d.Info(); err == nil {
+			date = info.ModTime()
+		}
+	}
+
+	if ok && field.Type() == dateType && field.CanSet() {
+		field.Set(reflect.ValueOf(Date{date}))
+	}
+
+	return date
+}
+
+var dateType = reflect.TypeOf(Date{})
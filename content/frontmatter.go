@@ -0,0 +1,155 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/adrg/frontmatter"
+	"gopkg.in/yaml.v3"
+)
+
+// builtinFrontmatter holds the frontmatter keys LoadItems handles itself,
+// whether or not the content type declares them. They are decoded as any and
+// read leniently, as the content type may declare them with another shape:
+// they never fail a file that decodes into the type.
+type builtinFrontmatter struct {
+	Draft       any `yaml:"draft" json:"draft" toml:"draft"`
+	PublishDate any `yaml:"publishDate" json:"publishDate" toml:"publishDate"`
+	Aliases     any `yaml:"aliases" json:"aliases" toml:"aliases"`
+	Slug        any `yaml:"slug" json:"slug" toml:"slug"`
+	Series      any `yaml:"series" json:"series" toml:"series"`
+	SeriesPart  any `yaml:"seriesPart" json:"seriesPart" toml:"seriesPart"`
+}
+
+// draft reports whether the draft key is true. Other values, such as a
+// string a content type decodes itself, are not drafts.
+func (b *builtinFrontmatter) draft() bool {
+	draft, _ := b.Draft.(bool)
+	return draft
+}
+
+func (b *builtinFrontmatter) publishDate() (time.Time, error) {
+	switch v := b.PublishDate.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case string:
+		return parseDate(v)
+	default:
+		return time.Time{}, fmt.Errorf("invalid publishDate %v", v)
+	}
+}
+
+// aliases returns the aliases key as a list, accepting a single string or a
+// list and ignoring anything else.
+func (b *builtinFrontmatter) aliases() []string {
+	switch v := b.Aliases.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var aliases []string
+		for _, alias := range v {
+			if s, ok := alias.(string); ok {
+				aliases = append(aliases, s)
+			}
+		}
+		return aliases
+	default:
+		return nil
+	}
+}
+
+// slug returns the slug key, as text if it is a number.
+func (b *builtinFrontmatter) slug() string {
+	return scalarString(b.Slug)
+}
+
+// series returns the series key, as text if it is a number such as a year.
+func (b *builtinFrontmatter) series() string {
+	return scalarString(b.Series)
+}
+
+// scalarString returns v if it is a string, the text of v if it is a number,
+// and "" otherwise.
+func scalarString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int, int64, uint64, float64:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
+
+// seriesPart returns the seriesPart key if it is a whole number, and 0
+// otherwise. YAML, TOML and JSON decode numbers as int, int64 and float64.
+func (b *builtinFrontmatter) seriesPart() int {
+	switch v := b.SeriesPart.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// frontmatterTargets lets a single frontmatter block be decoded into several values.
+type frontmatterTargets []any
+
+func decodeAll(unmarshal frontmatter.UnmarshalFunc) frontmatter.UnmarshalFunc {
+	return func(data []byte, v any) error {
+		for _, target := range v.(frontmatterTargets) {
+			if err := unmarshal(data, target); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// frontmatterFormats are the frontmatter package's default formats, decoding
+// into frontmatterTargets.
+var frontmatterFormats = []*frontmatter.Format{
+	frontmatter.NewFormat("---", "---", decodeAll(yaml.Unmarshal)),
+	frontmatter.NewFormat("---yaml", "---", decodeAll(yaml.Unmarshal)),
+	frontmatter.NewFormat("+++", "+++", decodeAll(toml.Unmarshal)),
+	frontmatter.NewFormat("---toml", "---", decodeAll(toml.Unmarshal)),
+	frontmatter.NewFormat(";;;", ";;;", decodeAll(json.Unmarshal)),
+	frontmatter.NewFormat("---json", "---", decodeAll(json.Unmarshal)),
+	{Start: "{", End: "}", Unmarshal: decodeAll(json.Unmarshal), UnmarshalDelims: true, RequiresNewLine: true},
+}
+
+// frontmatterOffset returns the number of lines of content before the
+// first line of its frontmatter as given to the decoder: the line of the
+// opening delimiter, or the line before it for JSON frontmatter that is
+// decoded along with its braces.
+func frontmatterOffset(content []byte) int {
+	for i, line := range strings.Split(string(content), "\n") {
+		switch strings.TrimSpace(line) {
+		case "":
+			continue
+		case "{":
+			return i
+		default:
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// parseFrontmatter decodes the frontmatter of content into each of targets
+// and returns the remaining content.
+func parseFrontmatter(content []byte, targets ...any) ([]byte, error) {
+	return frontmatter.Parse(bytes.NewReader(content), frontmatterTargets(targets), frontmatterFormats...)
+}
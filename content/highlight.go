@@ -0,0 +1,82 @@
+package content
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/labstack/echo/v4"
+)
+
+// DefaultHighlightStyle is the chroma style used for code blocks unless
+// HighlightStyle is passed to LoadItems.
+const DefaultHighlightStyle = "rrt"
+
+type stylesheet struct {
+	css  string
+	etag string
+}
+
+// stylesheets caches the generated CSS for each chroma style.
+var stylesheets sync.Map // style name => *stylesheet
+
+func highlightStylesheet(style string) (*stylesheet, error) {
+	if s, ok := stylesheets.Load(style); ok {
+		return s.(*stylesheet), nil
+	}
+
+	chromaStyle, ok := styles.Registry[style]
+	if !ok {
+		return nil, fmt.Errorf("unknown highlight style %q", style)
+	}
+
+	var buf bytes.Buffer
+	formatter := html.New(html.WithClasses(true), html.WithAllClasses(true))
+	if err := formatter.WriteCSS(&buf, chromaStyle); err != nil {
+		return nil, fmt.Errorf("failed to write CSS for style %q: %w", style, err)
+	}
+
+	s := &stylesheet{
+		css:  buf.String(),
+		etag: fmt.Sprintf(`"%x"`, md5.Sum(buf.Bytes())),
+	}
+	stylesheets.Store(style, s)
+	return s, nil
+}
+
+// HighlightCSS returns the stylesheet for the code blocks rendered with the
+// given chroma style. Rendered items only contain markup, so pages showing
+// highlighted code need to include this once.
+func HighlightCSS(style string) (string, error) {
+	s, err := highlightStylesheet(style)
+	if err != nil {
+		return "", err
+	}
+
+	return s.css, nil
+}
+
+// HighlightCSSHandler serves the stylesheet for the given chroma style.
+// Mount it next to the content routes, e.g.
+//
+//	e.GET("/content/highlight.css", content.HighlightCSSHandler(content.DefaultHighlightStyle))
+func HighlightCSSHandler(style string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		s, err := highlightStylesheet(style)
+		if err != nil {
+			return fmt.Errorf("failed to get highlight CSS: %w", err)
+		}
+
+		c.Response().Header().Set("ETag", s.etag)
+		c.Response().Header().Set("Cache-Control", "no-cache")
+		if c.Request().Header.Get("If-None-Match") == s.etag {
+			return c.NoContent(http.StatusNotModified)
+		}
+
+		return c.Blob(http.StatusOK, "text/css; charset=utf-8", []byte(s.css))
+	}
+}
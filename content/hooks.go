@@ -0,0 +1,70 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AfterLoader can be implemented by a content type to compute fields once
+// an item is loaded and rendered, such as a canonical URL or normalized
+// tags. AfterLoad may modify the item, including its Meta, but not its Slug:
+// links and the slug index are built from it before the hook runs. Set
+// slugs with the slug frontmatter key or Permalink instead.
+type AfterLoader[T any] interface {
+	AfterLoad(item *ContentItem[T]) error
+}
+
+// AfterLoadAller can be implemented by a content type for computations
+// across the whole collection, once every item is loaded. The items may be
+// modified in place but not added or removed, and their Slug must not
+// change.
+type AfterLoadAller[T any] interface {
+	AfterLoadAll(items []ContentItem[T]) error
+}
+
+// runAfterLoad calls the AfterLoad hook of each item and then AfterLoadAll,
+// if T implements them. paths holds the file of each item, for errors.
+// Hooks that change the slug of an item fail the load.
+func runAfterLoad[T any](items []ContentItem[T], paths []string) error {
+	slugs := make([]string, len(items))
+	for i, item := range items {
+		slugs[i] = item.Slug
+	}
+
+	var errs []error
+	for i := range items {
+		if hook, ok := any(&items[i].Meta).(AfterLoader[T]); ok {
+			if err := hook.AfterLoad(&items[i]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", paths[i], err))
+			}
+		}
+	}
+	errs = append(errs, changedSlugs(items, slugs, paths)...)
+	if len(errs) > 0 {
+		return fmt.Errorf("AfterLoad failed: %w", errors.Join(errs...))
+	}
+
+	var meta T
+	if hook, ok := any(&meta).(AfterLoadAller[T]); ok {
+		if err := hook.AfterLoadAll(items); err != nil {
+			return fmt.Errorf("AfterLoadAll failed: %w", err)
+		}
+		if errs := changedSlugs(items, slugs, paths); len(errs) > 0 {
+			return fmt.Errorf("AfterLoadAll failed: %w", errors.Join(errs...))
+		}
+	}
+
+	return nil
+}
+
+// changedSlugs returns an error for each item whose slug is no longer the
+// one in slugs.
+func changedSlugs[T any](items []ContentItem[T], slugs, paths []string) []error {
+	var errs []error
+	for i, item := range items {
+		if item.Slug != slugs[i] {
+			errs = append(errs, fmt.Errorf("%s: slug changed from %q to %q", paths[i], slugs[i], item.Slug))
+		}
+	}
+	return errs
+}
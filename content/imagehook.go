@@ -0,0 +1,144 @@
+package content
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/util"
+)
+
+// Image is an image of the content, passed to the OnImage hook before it
+// is rendered.
+type Image struct {
+	// Src is the URL the image is rendered with, e.g.
+	// /content/posts/photo.jpg for photo.jpg next to a post.
+	Src string
+	// Destination is the path or URL of the image as written in the
+	// markdown, or the target of an ![[embed]].
+	Destination string
+	// Alt and Title are plain text.
+	Alt   string
+	Title string
+	// Attrs holds the other attributes of the <img>, including the ones
+	// added by ResponsiveImages, such as width, height and srcset.
+	Attrs templ.Attributes
+	// Path is the content file the image is in, e.g. posts/hello.md.
+	Path string
+	// Embed is true for images embedded with ![[image.png]].
+	Embed bool
+
+	// source is the WebP <source> added by ResponsiveImages.
+	source string
+	xhtml  bool
+}
+
+// Tag returns the <img> element of img. It is wrapped in a <picture> if
+// ResponsiveImages made WebP variants.
+func (img *Image) Tag() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		var b strings.Builder
+		if img.source != "" {
+			b.WriteString("<picture>" + img.source)
+		}
+		b.WriteString(`<img src="` + html.EscapeString(img.Src) + `" alt="` + html.EscapeString(img.Alt) + `"`)
+		if img.Title != "" {
+			b.WriteString(` title="` + html.EscapeString(img.Title) + `"`)
+		}
+		if err := templ.RenderAttributes(ctx, &b, img.Attrs); err != nil {
+			return err
+		}
+		if img.xhtml {
+			b.WriteString(" />")
+		} else {
+			b.WriteString(">")
+		}
+		if img.source != "" {
+			b.WriteString("</picture>")
+		}
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+}
+
+// ImageHook is called for every image of the content. It may change img,
+// and returns the component to render in its place, or nil to render
+// img.Tag(). An error fails the load.
+type ImageHook func(img *Image) (templ.Component, error)
+
+// OnImage sets a hook called for every image of the content, e.g. to wrap
+// images with a title in a <figure>:
+//
+//	content.OnImage(func(img *content.Image) (templ.Component, error) {
+//		if img.Title == "" {
+//			return nil, nil
+//		}
+//		return figure(img), nil // a templ component using @img.Tag()
+//	})
+//
+// It runs after ResponsiveImages and before ImagePostProcess, and again for
+// images that are also part of an item's Excerpt.
+func OnImage(hook ImageHook) LoadOpt {
+	return func(config *loadConfig) {
+		config.imageHook = hook
+	}
+}
+
+// imageAttrs returns the attributes of n that are allowed on an <img>.
+func imageAttrs(n ast.Node, filter util.BytesFilter) templ.Attributes {
+	attrs := templ.Attributes{}
+	for _, a := range n.Attributes() {
+		if !filter.Contains(a.Name) {
+			continue
+		}
+		switch v := a.Value.(type) {
+		case []byte:
+			attrs[string(a.Name)] = string(v)
+		case string:
+			attrs[string(a.Name)] = v
+		default:
+			attrs[string(a.Name)] = fmt.Sprint(v)
+		}
+	}
+	return attrs
+}
+
+// writeImage renders img through ResponsiveImages, the OnImage hook and
+// the ImagePostProcess callback.
+func (r *markdownImagesRenderer) writeImage(w util.BufWriter, img *Image) (ast.WalkStatus, error) {
+	img.Path = r.path
+	img.xhtml = r.XHTML
+	if r.responsive != nil {
+		r.responsive.apply(img)
+	}
+
+	var component templ.Component
+	if r.hook != nil {
+		var err error
+		component, err = r.hook(img)
+		if err != nil {
+			return ast.WalkStop, fmt.Errorf("image hook failed for %s: %w", img.Destination, err)
+		}
+	}
+	if component == nil {
+		component = img.Tag()
+	}
+
+	var buf bytes.Buffer
+	if err := component.Render(context.Background(), &buf); err != nil {
+		return ast.WalkStop, fmt.Errorf("failed to render image %s: %w", img.Destination, err)
+	}
+
+	elt := buf.String()
+	if r.callback != nil {
+		elt = r.callback(elt)
+	}
+	_, _ = w.WriteString(elt)
+	return ast.WalkSkipChildren, nil
+}
@@ -1,13 +1,14 @@
 package content
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	stdhtml "html"
 	"path/filepath"
 	"strings"
 	"sync"
 
+	"github.com/a-h/templ"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
 	"github.com/yuin/goldmark/renderer"
@@ -17,24 +18,31 @@ import (
 )
 
 type markdownImages struct {
-	parentPath  string
-	callback    func(imageTag string) string
-	resolveLink func(target string) string
+	parentPath string
+	// path is the content file being rendered.
+	path     string
+	callback func(imageTag string) string
+	hook     ImageHook
+	// resolveWikilink returns the href of a wikilink, or false if its
+	// target does not exist.
+	resolveWikilink func(link *wikilink.Node) (string, bool)
+	// responsive is set by the ResponsiveImages option.
+	responsive *imageProcessor
 }
 
 // Extend implements goldmark.Extender.
 func (e *markdownImages) Extend(m goldmark.Markdown) {
 	m.Renderer().AddOptions(renderer.WithNodeRenderers(
 		// Use priority 100 to override default wikilink renderer (lower number = higher priority)
-		util.Prioritized(newMarkdownImagesRenderer(e.parentPath, e.callback, e.resolveLink), 100),
+		util.Prioritized(newMarkdownImagesRenderer(e), 100),
 	))
 }
 
+// markdownImagesRenderer reads its settings through the extension so that
+// parentPath can change between files rendered by the same pipeline.
 type markdownImagesRenderer struct {
 	html.Config
-	parentPath  string
-	callback    func(imageTag string) string
-	resolveLink func(target string) string
+	*markdownImages
 
 	// hasDest records whether a node had a destination when we resolved
 	// it. This is needed to decide whether a closing </a> must be added
@@ -73,38 +81,18 @@ func (r *markdownImagesRenderer) renderImage(w util.BufWriter, source []byte, no
 	}
 
 	n := node.(*ast.Image)
-	elt := ""
-	elt += `<img src="`
-
+	src := ""
 	if r.Unsafe || !html.IsDangerousURL(n.Destination) {
-		elt += r.encodeImage(n.Destination)
-	}
-	elt += `" alt="`
-	elt += string(nodeToHTMLText(n, source))
-	elt += `"`
-	if n.Title != nil {
-		elt += ` title="`
-		elt += string(n.Title)
-		elt += `"`
-	}
-
-	buf := &bytes.Buffer{}
-	if n.Attributes() != nil {
-		html.RenderAttributes(bufio.NewWriter(buf), n, html.ImageAttributeFilter)
-	}
-	elt += buf.String()
-
-	if r.XHTML {
-		elt += " />"
-	} else {
-		elt += ">"
+		src = r.encodeImage(n.Destination)
 	}
 
-	if r.callback != nil {
-		elt = r.callback(elt)
-	}
-	_, _ = w.WriteString(elt)
-	return ast.WalkSkipChildren, nil
+	return r.writeImage(w, &Image{
+		Src:         src,
+		Destination: string(n.Destination),
+		Alt:         stdhtml.UnescapeString(string(nodeToHTMLText(n, source))),
+		Title:       stdhtml.UnescapeString(string(n.Title)),
+		Attrs:       imageAttrs(n, html.ImageAttributeFilter),
+	})
 }
 
 // renderWikilink handles Obsidian embed syntax: ![[image.png]]
@@ -131,9 +119,15 @@ func (r *markdownImagesRenderer) exitWikilink(w util.BufWriter, link *wikilink.N
 
 func (r *markdownImagesRenderer) enterWikilink(w util.BufWriter, link *wikilink.Node) (ast.WalkStatus, error) {
 	if !link.Embed {
+		dest, ok := r.resolveWikilink(link)
+		if !ok {
+			// Broken link, render the label only
+			return ast.WalkContinue, nil
+		}
+
 		r.hasDest.Store(link, struct{}{})
 		_, _ = w.WriteString(`<a href="`)
-		_, _ = w.Write(util.URLEscape([]byte(r.resolveLink(string(link.Target))), true /* resolve references */))
+		_, _ = w.Write(util.URLEscape([]byte(dest), true /* resolve references */))
 		_, _ = w.WriteString(`">`)
 		return ast.WalkContinue, nil
 	}
@@ -154,24 +148,13 @@ func (r *markdownImagesRenderer) enterWikilink(w util.BufWriter, link *wikilink.
 	}
 
 	// Render as image
-	elt := ""
-	elt += `<img src="`
-	elt += r.encodeImage([]byte(basename))
-	elt += `" alt="`
-	elt += basename
-	elt += `"`
-
-	if r.XHTML {
-		elt += " />"
-	} else {
-		elt += ">"
-	}
-
-	if r.callback != nil {
-		elt = r.callback(elt)
-	}
-	_, _ = w.WriteString(elt)
-	return ast.WalkSkipChildren, nil
+	return r.writeImage(w, &Image{
+		Src:         r.encodeImage([]byte(basename)),
+		Destination: target,
+		Alt:         basename,
+		Attrs:       templ.Attributes{},
+		Embed:       true,
+	})
 }
 
 func nodeToHTMLText(n ast.Node, source []byte) []byte {
@@ -194,10 +177,8 @@ func (r *markdownImagesRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegi
 	reg.Register(wikilink.Kind, r.renderWikilink)
 }
 
-func newMarkdownImagesRenderer(parentPath string, callback func(imageTag string) string, resolveLink func(target string) string) renderer.NodeRenderer {
+func newMarkdownImagesRenderer(e *markdownImages) renderer.NodeRenderer {
 	return &markdownImagesRenderer{
-		parentPath:  parentPath,
-		callback:    callback,
-		resolveLink: resolveLink,
+		markdownImages: e,
 	}
 }
@@ -0,0 +1,249 @@
+package content
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark/ast"
+	"go.abhg.dev/goldmark/wikilink"
+)
+
+// wikilinkTargets returns the targets of the document's wikilinks, in order.
+// Embeds and links within the same document are skipped.
+func (d *document) wikilinkTargets() []string {
+	var targets []string
+	_ = ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		link, ok := n.(*wikilink.Node)
+		if entering && ok && !link.Embed && len(link.Target) > 0 {
+			targets = append(targets, string(link.Target))
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return targets
+}
+
+// linkKey normalizes a wikilink target or slug for matching, so that
+// [[Some Post]] and [[2014/some-post.md]] both find 2014/some-post.
+func linkKey(target string) string {
+	target = strings.TrimSuffix(strings.TrimSpace(target), ".md")
+
+	segments := strings.Split(target, "/")
+	for i, s := range segments {
+		segments[i] = slugify(s)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// linkIndex maps link keys to the slugs of the items they refer to.
+type linkIndex map[string]string
+
+// newLinkIndex indexes items by their full slug, by its last segment (the
+// way Obsidian matches notes by file name) and by their aliases, in that
+// order of precedence. The first item wins when names clash.
+func newLinkIndex[T any](parsed []parsedItem[T]) linkIndex {
+	index := make(linkIndex)
+	add := func(key, slug string) {
+		if _, ok := index[key]; !ok {
+			index[key] = slug
+		}
+	}
+
+	for _, p := range parsed {
+		add(linkKey(p.item.Slug), p.item.Slug)
+	}
+	for _, p := range parsed {
+		add(linkKey(path.Base(p.item.Slug)), p.item.Slug)
+	}
+	for _, p := range parsed {
+		for _, alias := range p.aliases {
+			add(linkKey(alias), p.item.Slug)
+		}
+	}
+
+	return index
+}
+
+func (idx linkIndex) resolve(target string) (string, bool) {
+	slug, ok := idx[linkKey(target)]
+	return slug, ok
+}
+
+// BrokenLink is a wikilink whose target matches no item.
+type BrokenLink struct {
+	// Path is the file containing the link.
+	Path   string
+	Target string
+}
+
+// BrokenLinksError is returned by LoadItems in Strict mode when wikilinks
+// point to items that do not exist.
+type BrokenLinksError struct {
+	Links []BrokenLink
+}
+
+func (e *BrokenLinksError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d broken wikilinks:", len(e.Links))
+	for _, l := range e.Links {
+		fmt.Fprintf(&b, "\n\t%s: [[%s]]", l.Path, l.Target)
+	}
+	return b.String()
+}
+
+// linkResult is the outcome of resolving the wikilinks of a collection.
+type linkResult struct {
+	index  linkIndex
+	broken []BrokenLink
+}
+
+// linkItems resolves the wikilinks of every item, records Links and
+// Backlinks, and reports the links that could not be resolved.
+func linkItems[T any](parsed []parsedItem[T]) linkResult {
+	result := linkResult{index: newLinkIndex(parsed)}
+	backlinks := make(map[string][]string)
+
+	for i := range parsed {
+		item := &parsed[i].item
+		var broken []string
+		for _, target := range parsed[i].doc.wikilinkTargets() {
+			slug, ok := result.index.resolve(target)
+			if !ok {
+				if !slices.Contains(broken, target) {
+					broken = append(broken, target)
+					result.broken = append(result.broken, BrokenLink{Path: parsed[i].path, Target: target})
+				}
+				continue
+			}
+			if slug == item.Slug || slices.Contains(item.Links, slug) {
+				continue
+			}
+			item.Links = append(item.Links, slug)
+			backlinks[slug] = append(backlinks[slug], item.Slug)
+		}
+	}
+
+	for i := range parsed {
+		parsed[i].item.Backlinks = backlinks[parsed[i].item.Slug]
+	}
+
+	return result
+}
+
+// resolver returns the function used to render wikilinks. Unless ResolveLink
+// was given, targets resolve to the matching item's slug under the link
+// prefix, with [[note#heading]] fragments turned into heading ids.
+func (r linkResult) resolver(cfg loadConfig, dirName string) func(link *wikilink.Node) (string, bool) {
+	if cfg.resolveLink != nil {
+		return func(link *wikilink.Node) (string, bool) {
+			return cfg.resolveLink(string(link.Target)), true
+		}
+	}
+
+	prefix := cfg.linkPrefix
+	if prefix == "" {
+		prefix = "/" + dirName + "/"
+	}
+
+	return func(link *wikilink.Node) (string, bool) {
+		var fragment string
+		if len(link.Fragment) > 0 {
+			fragment = "#" + slugify(string(link.Fragment))
+		}
+
+		if len(link.Target) == 0 {
+			return fragment, fragment != ""
+		}
+
+		slug, ok := r.index.resolve(string(link.Target))
+		if !ok {
+			return "", false
+		}
+
+		return prefix + slug + fragment, true
+	}
+}
+
+// BrokenLinks returns the wikilinks of type T that matched no item in the
+// last load.
+func BrokenLinks[T any]() ([]BrokenLink, error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.brokenLinks, nil
+}
+
+// Backlinks returns the visible items of type T that link to the item with
+// the given slug.
+func Backlinks[T any](slug string) ([]ContentItem[T], error) {
+	item, err := GetItem[T](slug)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var items []ContentItem[T]
+	for _, from := range item.Backlinks {
+		if i, ok := c.bySlug[from]; ok && c.published(c.items[i], now) {
+			items = append(items, c.view(c.items[i], now))
+		}
+	}
+
+	return items, nil
+}
+
+// LinkGraph is the wikilink graph of a collection, shaped for graph views
+// such as d3-force.
+type LinkGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Links []GraphLink `json:"links"`
+}
+
+type GraphNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type GraphLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Graph returns the wikilink graph between the visible items of type T.
+// Nodes are identified by slug and titled by the title frontmatter key.
+func Graph[T any]() (LinkGraph, error) {
+	items, err := GetItems[T]()
+	if err != nil {
+		return LinkGraph{}, fmt.Errorf("failed to get items: %w", err)
+	}
+
+	visible := make(map[string]bool, len(items))
+	for _, item := range items {
+		visible[item.Slug] = true
+	}
+
+	graph := LinkGraph{Nodes: []GraphNode{}, Links: []GraphLink{}}
+	for _, item := range items {
+		title := metaString(reflect.ValueOf(item.Meta), "title")
+		graph.Nodes = append(graph.Nodes, GraphNode{ID: item.Slug, Title: title})
+		for _, target := range item.Links {
+			if visible[target] {
+				graph.Links = append(graph.Links, GraphLink{Source: item.Slug, Target: target})
+			}
+		}
+	}
+
+	return graph, nil
+}
@@ -0,0 +1,111 @@
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+)
+
+// newMarkdown builds the goldmark pipeline shared by every file of a load.
+func newMarkdown(cfg loadConfig, images *markdownImages) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithExtensions(cfg.extensions...),
+		goldmark.WithExtensions(
+			images,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(cfg.highlightStyle),
+				highlighting.WithFormatOptions(html.WithClasses(true), html.WithAllClasses(true)),
+				highlighting.WithGuessLanguage(true),
+			),
+		),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithParserOptions(cfg.parserOptions...),
+		goldmark.WithRendererOptions(cfg.rendererOptions...),
+	)
+}
+
+// document is a parsed markdown file.
+type document struct {
+	source []byte
+	root   ast.Node
+}
+
+func parseMarkdown(markdown goldmark.Markdown, source []byte) *document {
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	root := markdown.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	return &document{source: source, root: root}
+}
+
+func (d *document) render(markdown goldmark.Markdown) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Renderer().Render(&buf, d.source, d.root); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// renderNodes renders the given top-level nodes of the document.
+func (d *document) renderNodes(markdown goldmark.Markdown, nodes []ast.Node) (string, error) {
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		if err := markdown.Renderer().Render(&buf, d.source, n); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// headingAnchorClass is the class of the anchors added by HeadingAnchors.
+const headingAnchorClass = "heading-anchor"
+
+// isHeadingAnchor reports whether link is an anchor added by HeadingAnchors.
+func isHeadingAnchor(link *ast.Link) bool {
+	class, ok := link.AttributeString("class")
+	if !ok {
+		return false
+	}
+	b, ok := class.([]byte)
+	return ok && string(b) == headingAnchorClass
+}
+
+// plainText returns the text of n and its descendants without any markup,
+// with whitespace collapsed to single spaces.
+func plainText(n ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock {
+				buf.WriteByte(' ')
+			}
+			return ast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *ast.Link:
+			if isHeadingAnchor(n) {
+				return ast.WalkSkipChildren, nil
+			}
+		case *ast.Text:
+			buf.Write(n.Segment.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(n.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
@@ -0,0 +1,113 @@
+package content
+
+import (
+	"reflect"
+	"strings"
+)
+
+// metaField returns the field of the struct v decoded from the frontmatter key,
+// matching the yaml tag first and the field name otherwise.
+func metaField(v reflect.Value, key string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+
+	i, ok := metaFieldIndex(v.Type(), key)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	return v.Field(i), true
+}
+
+// metaFieldIndex returns the index of the field of struct type t named by key,
+// either as its frontmatter key or its Go name.
+func metaFieldIndex(t reflect.Type, key string) (int, bool) {
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && metaKey(f) == key {
+			return i, true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.IsExported() && f.Name == key {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// metaKey returns the frontmatter key a struct field is decoded from.
+func metaKey(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+
+	return name
+}
+
+// metaString returns the string value of the frontmatter key, or "" if the
+// meta has no such string field.
+func metaString(v reflect.Value, key string) string {
+	f, ok := metaField(v, key)
+	if !ok || f.Kind() != reflect.String {
+		return ""
+	}
+
+	return f.String()
+}
+
+// ccfTag parses a field's ccf struct tag, e.g. `ccf:"taxonomy,ref=Author"`,
+// into its options. Options without a value map to "".
+func ccfTag(f reflect.StructField) map[string]string {
+	opts := make(map[string]string)
+	tag, ok := f.Tag.Lookup("ccf")
+	if !ok {
+		return opts
+	}
+
+	for opt := range strings.SplitSeq(tag, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		if k != "" {
+			opts[k] = v
+		}
+	}
+
+	return opts
+}
+
+// metaStrings returns the value of a string or []string field as a slice.
+func metaStrings(v reflect.Value) []string {
+	switch v.Kind() {
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		return []string{v.String()}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		strs := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			strs = append(strs, v.Index(i).String())
+		}
+		return strs
+	}
+
+	return nil
+}
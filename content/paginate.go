@@ -0,0 +1,46 @@
+package content
+
+// Page is one page of a paginated list.
+type Page[E any] struct {
+	Items []E
+	// Page is the 1-based number of this page.
+	Page    int
+	PerPage int
+	// Total is the number of items across all pages.
+	Total     int
+	PageCount int
+	// Prev and Next are the neighbouring page numbers, or 0 if there is none.
+	Prev int
+	Next int
+}
+
+// Paginate returns page number page of items, with perPage items per page.
+// A perPage of 0 or less puts every item on one page. Pages past the last
+// one have no items, so handlers can check Page > PageCount to return a 404.
+func Paginate[E any](items []E, page, perPage int) Page[E] {
+	total := len(items)
+	if perPage <= 0 {
+		perPage = max(total, 1)
+	}
+	page = max(page, 1)
+
+	p := Page[E]{
+		Page:      page,
+		PerPage:   perPage,
+		Total:     total,
+		PageCount: max((total+perPage-1)/perPage, 1),
+	}
+
+	if start := (page - 1) * perPage; start < total {
+		p.Items = items[start:min(start+perPage, total)]
+	}
+
+	if page > 1 {
+		p.Prev = min(page-1, p.PageCount)
+	}
+	if page < p.PageCount {
+		p.Next = page + 1
+	}
+
+	return p
+}
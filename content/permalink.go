@@ -0,0 +1,115 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// permalinkToken matches a :name token of a permalink pattern.
+var permalinkToken = regexp.MustCompile(`:([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Permalink sets the pattern item slugs are built from, instead of their
+// path. Tokens are replaced by slugified values:
+//
+//	:year, :month, :day  the item's Date
+//	:title               the title frontmatter field, or else :filename
+//	:filename            the file name without extension or date prefix
+//	:path                the default, path-based slug
+//	:<key>               any other frontmatter field
+//
+// For example ":year/:month/:title" turns posts/hello.md dated 2024-01-02
+// into 2024/01/hello. A slug frontmatter key overrides the pattern.
+func Permalink(pattern string) LoadOpt {
+	return func(config *loadConfig) {
+		config.permalink = pattern
+	}
+}
+
+// checkPermalink reports :key tokens of pattern that match no field of t.
+func checkPermalink(pattern string, t reflect.Type) error {
+	var errs []error
+	for _, m := range permalinkToken.FindAllStringSubmatch(pattern, -1) {
+		switch m[1] {
+		case "year", "month", "day", "title", "filename", "path":
+			continue
+		}
+		if _, ok := metaFieldIndex(t, m[1]); !ok {
+			errs = append(errs, fmt.Errorf("unknown permalink token %s", m[0]))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// expandPermalink builds the slug of an item from a permalink pattern.
+// relPath is the default slug and date the item's date.
+func expandPermalink(pattern string, meta reflect.Value, relPath string, date time.Time) string {
+	filename := slugify(fileDatePrefix.ReplaceAllString(path.Base(relPath), ""))
+
+	slug := permalinkToken.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch key := token[1:]; key {
+		case "year":
+			return date.Format("2006")
+		case "month":
+			return date.Format("01")
+		case "day":
+			return date.Format("02")
+		case "filename":
+			return filename
+		case "path":
+			return relPath
+		case "title":
+			if title := slugify(metaString(meta, "title")); title != "" {
+				return title
+			}
+			return filename
+		default:
+			f, ok := metaField(meta, key)
+			if !ok {
+				return ""
+			}
+			return slugify(fmt.Sprint(f.Interface()))
+		}
+	})
+
+	return cleanSlug(slug)
+}
+
+// cleanSlug removes empty segments and leading or trailing slashes from slug.
+func cleanSlug(slug string) string {
+	var segments []string
+	for segment := range strings.SplitSeq(slug, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// slugOverride slugifies each segment of a slug frontmatter value.
+func slugOverride(slug string) string {
+	segments := strings.Split(slug, "/")
+	for i, segment := range segments {
+		segments[i] = slugify(segment)
+	}
+	return cleanSlug(strings.Join(segments, "/"))
+}
+
+// duplicateSlugs returns an error for every slug shared by several items.
+// paths holds the file of each slug.
+func duplicateSlugs(slugs, paths []string) []error {
+	first := make(map[string]int, len(slugs))
+	var errs []error
+	for i, slug := range slugs {
+		if j, ok := first[slug]; ok {
+			errs = append(errs, fmt.Errorf("duplicate slug %q: %s and %s", slug, paths[j], paths[i]))
+			continue
+		}
+		first[slug] = i
+	}
+	return errs
+}
@@ -0,0 +1,241 @@
+package content
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// QueryBuilder filters, sorts and pages the items of a collection.
+// Build one with Query and run it with Items, First or Count.
+type QueryBuilder[T any] struct {
+	where  []func(ContentItem[T]) bool
+	sorts  []func(a, b ContentItem[T]) int
+	offset int
+	limit  int
+	err    error
+}
+
+// Query starts a query over the visible items of type T.
+//
+//	posts, err := content.Query[Post]().
+//		Where(func(p content.ContentItem[Post]) bool { return p.Meta.Author == "jane" }).
+//		SortBy("date", true).
+//		Limit(10).
+//		Items()
+func Query[T any]() *QueryBuilder[T] {
+	return &QueryBuilder[T]{limit: -1}
+}
+
+// Where keeps only the items for which fn returns true. Multiple calls
+// must all match.
+func (q *QueryBuilder[T]) Where(fn func(ContentItem[T]) bool) *QueryBuilder[T] {
+	q.where = append(q.where, fn)
+	return q
+}
+
+// SortBy sorts items by a frontmatter field, named by its frontmatter key or
+// Go field name, or by a ContentItem field such as "Slug" or "PublishDate".
+// Later sorts break ties left by earlier ones.
+func (q *QueryBuilder[T]) SortBy(field string, desc bool) *QueryBuilder[T] {
+	key, err := sortKey[T](field)
+	if err != nil {
+		q.err = err
+		return q
+	}
+
+	return q.SortFunc(func(a, b ContentItem[T]) int {
+		c := compareValues(key(a), key(b))
+		if desc {
+			return -c
+		}
+		return c
+	})
+}
+
+// SortFunc sorts items with cmp, as in slices.SortFunc.
+func (q *QueryBuilder[T]) SortFunc(cmp func(a, b ContentItem[T]) int) *QueryBuilder[T] {
+	q.sorts = append(q.sorts, cmp)
+	return q
+}
+
+// Offset skips the first n matching items.
+func (q *QueryBuilder[T]) Offset(n int) *QueryBuilder[T] {
+	q.offset = n
+	return q
+}
+
+// Limit returns at most n items.
+func (q *QueryBuilder[T]) Limit(n int) *QueryBuilder[T] {
+	q.limit = n
+	return q
+}
+
+// Items runs the query.
+func (q *QueryBuilder[T]) Items() ([]ContentItem[T], error) {
+	items, err := q.matching()
+	if err != nil {
+		return nil, err
+	}
+
+	if q.offset >= len(items) {
+		return nil, nil
+	}
+	items = items[max(q.offset, 0):]
+
+	if q.limit >= 0 && q.limit < len(items) {
+		items = items[:q.limit]
+	}
+
+	return items, nil
+}
+
+// First returns the first item of the query. The error matches ErrNotFound
+// if no item matches.
+func (q *QueryBuilder[T]) First() (ContentItem[T], error) {
+	items, err := q.Limit(1).Items()
+	if err != nil {
+		return ContentItem[T]{}, err
+	}
+
+	if len(items) == 0 {
+		return ContentItem[T]{}, fmt.Errorf("no %v matches the query: %w", reflect.TypeOf((*T)(nil)).Elem(), ErrNotFound)
+	}
+
+	return items[0], nil
+}
+
+// Count returns the number of matching items, ignoring Offset and Limit.
+func (q *QueryBuilder[T]) Count() (int, error) {
+	items, err := q.matching()
+	return len(items), err
+}
+
+// matching returns the filtered and sorted items, before paging.
+func (q *QueryBuilder[T]) matching() ([]ContentItem[T], error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	all, err := GetItems[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ContentItem[T], 0, len(all))
+	for _, item := range all {
+		if q.matches(item) {
+			items = append(items, item)
+		}
+	}
+
+	if len(q.sorts) > 0 {
+		slices.SortStableFunc(items, func(a, b ContentItem[T]) int {
+			for _, sort := range q.sorts {
+				if c := sort(a, b); c != 0 {
+					return c
+				}
+			}
+			return 0
+		})
+	}
+
+	return items, nil
+}
+
+func (q *QueryBuilder[T]) matches(item ContentItem[T]) bool {
+	for _, where := range q.where {
+		if !where(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortKey resolves a field name to a function returning its value for an item.
+func sortKey[T any](field string) (func(ContentItem[T]) reflect.Value, error) {
+	metaType := reflect.TypeOf((*T)(nil)).Elem()
+	if i, ok := metaFieldIndex(metaType, field); ok {
+		if !sortable(metaType.Field(i).Type) {
+			return nil, fmt.Errorf("cannot sort %v by field %q of type %v", metaType, field, metaType.Field(i).Type)
+		}
+		return func(item ContentItem[T]) reflect.Value {
+			return reflect.ValueOf(&item.Meta).Elem().Field(i)
+		}, nil
+	}
+
+	itemType := reflect.TypeOf((*ContentItem[T])(nil)).Elem()
+	if f, ok := itemType.FieldByName(field); ok && sortable(f.Type) {
+		return func(item ContentItem[T]) reflect.Value {
+			return reflect.ValueOf(&item).Elem().FieldByIndex(f.Index)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cannot sort %v by unknown field %q", metaType, field)
+}
+
+func sortable(t reflect.Type) bool {
+	if t == timeType || t == dateType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		return sortable(t.Elem())
+	}
+
+	return false
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// compareValues orders two values of the same sortable type.
+func compareValues(a, b reflect.Value) int {
+	if a.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time))
+	}
+	if a.Type() == dateType {
+		return a.Interface().(Date).Compare(b.Interface().(Date).Time)
+	}
+
+	switch a.Kind() {
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Bool:
+		switch {
+		case a.Bool() == b.Bool():
+			return 0
+		case a.Bool():
+			return 1
+		default:
+			return -1
+		}
+	case reflect.Pointer:
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		default:
+			return compareValues(a.Elem(), b.Elem())
+		}
+	}
+
+	return 0
+}
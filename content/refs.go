@@ -0,0 +1,129 @@
+package content
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// RefError is a reference to an item that does not exist, from a field
+// tagged `ccf:"ref=Type"`.
+type RefError struct {
+	// Type and Slug identify the referring item.
+	Type string
+	Slug string
+	// Key is the frontmatter key of the reference, Target the referenced type.
+	Key    string
+	Target string
+	Ref    string
+}
+
+func (e *RefError) Error() string {
+	return fmt.Sprintf("%s %q: %s %q matches no %s", e.Type, e.Slug, e.Key, e.Ref, e.Target)
+}
+
+// refField is a field of a content type tagged `ccf:"ref=Type"`.
+type refField struct {
+	index  int
+	key    string
+	target string
+}
+
+// refFields returns the reference fields of struct type t.
+func refFields(t reflect.Type) []refField {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var refs []refField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if target := ccfTag(f)["ref"]; target != "" && f.IsExported() {
+			refs = append(refs, refField{index: i, key: metaKey(f), target: target})
+		}
+	}
+	return refs
+}
+
+// refTarget is the view of a stored collection needed to check references
+// between collections.
+type refTarget interface {
+	hasSlug(slug string) bool
+	danglingRefs(targets map[string]refTarget) []error
+}
+
+func (c *collection[T]) hasSlug(slug string) bool {
+	_, ok := c.bySlug[slug]
+	return ok
+}
+
+// danglingRefs returns a RefError for every reference of the collection's
+// items to a slug missing from its target collection. targets maps type
+// names to collections, or to nil for names of more than one type. Empty references are
+// allowed; use `ccf:"required"` to reject them.
+func (c *collection[T]) danglingRefs(targets map[string]refTarget) []error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	refs := refFields(t)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, ref := range refs {
+		target, ok := targets[ref.target]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s references %s in %s, which is not loaded", t.Name(), ref.target, ref.key))
+			continue
+		}
+		if target == nil {
+			errs = append(errs, fmt.Errorf("%s references %s in %s, which names more than one loaded type", t.Name(), ref.target, ref.key))
+			continue
+		}
+
+		for _, item := range c.items {
+			for _, slug := range metaStrings(reflect.ValueOf(item.Meta).Field(ref.index)) {
+				if slug != "" && !target.hasSlug(slug) {
+					errs = append(errs, &RefError{Type: t.Name(), Slug: item.Slug, Key: ref.key, Target: ref.target, Ref: slug})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// CheckRefs validates the references of every loaded collection, i.e. the
+// string or []string fields tagged `ccf:"ref=Author"` that hold slugs of
+// items of the type named Author. Call it once all collections are loaded;
+// the generated Initialize function does. The error joins a *RefError for
+// every dangling reference. Types are referenced by name alone, so a
+// reference to a name shared by types of different packages is an error.
+func CheckRefs() error {
+	storeMu.RLock()
+	types := slices.SortedFunc(maps.Keys(store), func(a, b reflect.Type) int {
+		return strings.Compare(a.PkgPath()+"."+a.Name(), b.PkgPath()+"."+b.Name())
+	})
+	collections := make([]refTarget, len(types))
+	targets := make(map[string]refTarget, len(types))
+	for i, t := range types {
+		collections[i] = store[t].(refTarget)
+		if _, ok := targets[t.Name()]; ok {
+			targets[t.Name()] = nil
+		} else {
+			targets[t.Name()] = collections[i]
+		}
+	}
+	storeMu.RUnlock()
+
+	var errs []error
+	for _, c := range collections {
+		errs = append(errs, c.danglingRefs(targets)...)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to resolve references: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
@@ -0,0 +1,350 @@
+package content
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultImageWidths are the widths of the variants made by
+// ResponsiveImages, in pixels.
+var DefaultImageWidths = []int{320, 640, 960, 1280, 1920}
+
+const (
+	// DefaultImagePath is the URL path image variants are served under.
+	DefaultImagePath = "/_images/"
+	// defaultImageSizes makes browsers pick a variant for the full viewport
+	// width unless ImageOptions.Sizes says otherwise.
+	defaultImageSizes  = "100vw"
+	defaultJPEGQuality = 80
+)
+
+// ImageOptions configures ResponsiveImages and ImageHandler, which need the
+// same options. The zero value uses the defaults.
+type ImageOptions struct {
+	// Widths are the widths of the variants, DefaultImageWidths if empty.
+	// Images are never scaled up: widths above an image's own are skipped.
+	Widths []int
+	// Sizes is the sizes attribute of the images, "100vw" if empty, e.g.
+	// "(min-width: 48rem) 48rem, 100vw" for a 48rem content column.
+	Sizes string
+	// Quality is the JPEG quality of the variants, 80 if 0.
+	Quality int
+	// CacheDir is where the variants are written and kept between runs.
+	// Defaults to ccf/images in the user cache directory.
+	CacheDir string
+	// Path is the URL path ImageHandler is mounted under, DefaultImagePath
+	// if empty.
+	Path string
+	// EncodeWebP, if set, is used to also encode every variant as WebP, and
+	// images are wrapped in a <picture> offering them first. Go can decode
+	// WebP but has no pure-Go encoder, so this takes one from elsewhere, e.g.
+	// github.com/chai2010/webp (cgo). Without it variants keep the format
+	// of the original, or JPEG for WebP originals.
+	EncodeWebP func(w io.Writer, img image.Image) error
+}
+
+func (o ImageOptions) widths() []int {
+	if len(o.Widths) == 0 {
+		return DefaultImageWidths
+	}
+	return o.Widths
+}
+
+func (o ImageOptions) sizes() string {
+	if o.Sizes == "" {
+		return defaultImageSizes
+	}
+	return o.Sizes
+}
+
+func (o ImageOptions) quality() int {
+	if o.Quality == 0 {
+		return defaultJPEGQuality
+	}
+	return o.Quality
+}
+
+func (o ImageOptions) cacheDir() string {
+	if o.CacheDir != "" {
+		return o.CacheDir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "ccf", "images")
+}
+
+func (o ImageOptions) path() string {
+	if o.Path == "" {
+		return DefaultImagePath
+	}
+	return strings.TrimSuffix(o.Path, "/") + "/"
+}
+
+// ResponsiveImages makes the images of the content, written as relative
+// paths or ![[embeds]], responsive: they get resized variants in a srcset,
+// their intrinsic width and height, and loading="lazy". Variants are
+// written to opts.CacheDir the first time they are needed and must be served
+// by ImageHandler with the same options. All images are lazy loaded, but
+// only JPEG, PNG and WebP images are resized; GIFs just get their size.
+//
+//	opts := content.ImageOptions{Sizes: "(min-width: 48rem) 48rem, 100vw"}
+//	e.GET(content.DefaultImagePath+"*", content.ImageHandler(opts))
+//	content.Initialize(e, content.ResponsiveImages(opts))
+func ResponsiveImages(opts ImageOptions) LoadOpt {
+	return func(config *loadConfig) {
+		config.responsiveImages = &opts
+	}
+}
+
+// ImageHandler serves the variants made by ResponsiveImages with the same
+// options. Mount it at the options' Path followed by "*". Variant names
+// change with their content, so they are cached for good.
+func ImageHandler(opts ImageOptions) echo.HandlerFunc {
+	dir := opts.cacheDir()
+	return func(c echo.Context) error {
+		name := path.Base(c.Param("*"))
+		if name == "." || name == "/" || strings.HasPrefix(name, ".") {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+
+		c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		return c.File(filepath.Join(dir, name))
+	}
+}
+
+// imageProcessor makes the variants of the images read from fsys.
+type imageProcessor struct {
+	opts ImageOptions
+	fsys fs.FS
+}
+
+// apply adds the attributes of ResponsiveImages to img, keeping the ones it
+// already has, such as a {width=300} set in the markdown.
+func (p *imageProcessor) apply(img *Image) {
+	has := func(name string) bool {
+		_, ok := img.Attrs[name]
+		return ok
+	}
+	if !has("loading") {
+		img.Attrs["loading"] = "lazy"
+	}
+
+	name, ok := strings.CutPrefix(img.Src, "/content/")
+	if !ok {
+		return
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp":
+	default:
+		return
+	}
+
+	v, err := p.variants(name)
+	if err != nil {
+		slog.Warn("failed to make image variants", "src", img.Src, "error", err)
+		return
+	}
+
+	if !has("width") && !has("height") {
+		img.Attrs["width"] = strconv.Itoa(v.width)
+		img.Attrs["height"] = strconv.Itoa(v.height)
+	}
+	if len(v.srcset) > 0 && !has("srcset") {
+		img.Src = v.srcset[len(v.srcset)-1].url
+		img.Attrs["srcset"] = v.srcset.String()
+		img.Attrs["sizes"] = p.opts.sizes()
+		if len(v.webp) > 0 {
+			img.source = `<source type="image/webp" srcset="` + html.EscapeString(v.webp.String()) +
+				`" sizes="` + html.EscapeString(p.opts.sizes()) + `">`
+		}
+	}
+}
+
+type srcset []imageVariant
+
+type imageVariant struct {
+	url   string
+	width int
+}
+
+func (s srcset) String() string {
+	entries := make([]string, len(s))
+	for i, v := range s {
+		entries[i] = strings.ReplaceAll(v.url, " ", "%20") + " " + strconv.Itoa(v.width) + "w"
+	}
+	return strings.Join(entries, ", ")
+}
+
+type imageVariants struct {
+	width, height int
+	// srcset and webp are ordered by width. They are empty if the image is
+	// smaller than every variant width.
+	srcset srcset
+	webp   srcset
+}
+
+// variants returns the variants of the image at name in fsys, encoding the
+// ones missing from the cache directory.
+func (p *imageProcessor) variants(name string) (imageVariants, error) {
+	data, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return imageVariants{}, err
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return imageVariants{}, fmt.Errorf("failed to read image size: %w", err)
+	}
+
+	v := imageVariants{width: config.Width, height: config.Height}
+	if format == "gif" {
+		return v, nil
+	}
+
+	// The widths to offer: every variant width below the image's, and the
+	// image's own if a variant would be as large.
+	var widths []int
+	for _, w := range p.opts.widths() {
+		if w > 0 && w < config.Width {
+			widths = append(widths, w)
+		}
+	}
+	slices.Sort(widths)
+	widths = slices.Compact(widths)
+	if slices.Max(p.opts.widths()) >= config.Width {
+		widths = append(widths, config.Width)
+	}
+	if len(widths) == 0 || widths[0] == config.Width {
+		return v, nil
+	}
+
+	hash := sha256.New()
+	hash.Write(data)
+	fmt.Fprintf(hash, "%d", p.opts.quality())
+	prefix := fmt.Sprintf("%s-%s-", variantBase(name), hex.EncodeToString(hash.Sum(nil))[:12])
+
+	var decoded image.Image
+	decode := func() (image.Image, error) {
+		if decoded == nil {
+			decoded, _, err = image.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode image: %w", err)
+			}
+		}
+		return decoded, nil
+	}
+
+	ext := "." + format
+	if format == "webp" {
+		// Without a WebP encoder, variants of WebP images are JPEGs, or PNGs
+		// if they have transparency.
+		img, err := decode()
+		if err != nil {
+			return v, err
+		}
+		ext = ".jpeg"
+		if o, ok := img.(interface{ Opaque() bool }); ok && !o.Opaque() {
+			ext = ".png"
+		}
+	}
+
+	for _, w := range widths {
+		if w == config.Width && format != "webp" {
+			v.srcset = append(v.srcset, imageVariant{url: "/content/" + name, width: w})
+		} else {
+			file := prefix + strconv.Itoa(w) + ext
+			if err := p.writeVariant(file, w, config, decode); err != nil {
+				return v, err
+			}
+			v.srcset = append(v.srcset, imageVariant{url: p.opts.path() + file, width: w})
+		}
+
+		if p.opts.EncodeWebP != nil {
+			file := prefix + strconv.Itoa(w) + ".webp"
+			if err := p.writeVariant(file, w, config, decode); err != nil {
+				return v, err
+			}
+			v.webp = append(v.webp, imageVariant{url: p.opts.path() + file, width: w})
+		}
+	}
+
+	return v, nil
+}
+
+// writeVariant writes the image scaled to width w to file in the cache
+// directory, unless it is already there.
+func (p *imageProcessor) writeVariant(file string, w int, config image.Config, decode func() (image.Image, error)) error {
+	dir := p.opts.cacheDir()
+	if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+		return nil
+	}
+
+	src, err := decode()
+	if err != nil {
+		return err
+	}
+	h := max(1, (config.Height*w+config.Width/2)/config.Width)
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create image cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".variant-*")
+	if err != nil {
+		return fmt.Errorf("failed to create image variant: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	switch path.Ext(file) {
+	case ".png":
+		err = png.Encode(tmp, dst)
+	case ".webp":
+		err = p.opts.EncodeWebP(tmp, dst)
+	default:
+		err = jpeg.Encode(tmp, dst, &jpeg.Options{Quality: p.opts.quality()})
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode image variant %s: %w", file, err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, file)); err != nil {
+		return fmt.Errorf("failed to write image variant %s: %w", file, err)
+	}
+	return nil
+}
+
+// variantBase returns the file name of name without its extension, limited
+// to URL-safe characters.
+func variantBase(name string) string {
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if s := slugify(base); s != "" {
+		return s
+	}
+	return "image"
+}
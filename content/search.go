@@ -0,0 +1,370 @@
+package content
+
+import (
+	"cmp"
+	"html"
+	"math"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// titleWeight, metaWeight and bodyWeight are how much a word counts in
+	// the title, in other frontmatter text fields and in the body.
+	titleWeight = 5
+	metaWeight  = 2
+	bodyWeight  = 1
+	// prefixWeight scales matches of the last query word as a prefix, such
+	// as "config" for "configuration".
+	prefixWeight = 0.5
+	// bm25K1 controls how quickly repeated words stop adding to the score.
+	bm25K1 = 1.2
+	// snippetWords is the length of a search result snippet.
+	snippetWords = 24
+	// SearchLimit is the number of results served by SearchHandler unless
+	// the request has a limit parameter.
+	SearchLimit = 10
+)
+
+// stopWords are not indexed, as nearly every item has them.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "in": true, "is": true, "it": true, "its": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "were": true, "will": true, "with": true,
+}
+
+// SearchResult is an item matching a search query.
+type SearchResult[T any] struct {
+	Item  ContentItem[T]
+	Score float64
+	// Title and Snippet are HTML, with the matched words in <mark> elements.
+	// Snippet is an extract of the body around the first match.
+	Title   string
+	Snippet string
+}
+
+// searchIndex is an inverted index of the words of a collection's items.
+type searchIndex struct {
+	postings map[string][]posting // stem => items containing it
+	words    []string             // sorted indexed words, for prefix matches
+	stems    map[string]string    // word => stem
+	titles   []string
+	bodies   []string
+}
+
+type posting struct {
+	item   int
+	weight float64
+}
+
+// indexSearch indexes the title, the other string and []string frontmatter
+// fields of items and their plain-text bodies, if any. Fields tagged
+// `ccf:"nosearch"` are left out.
+func indexSearch[T any](items []ContentItem[T], bodies []string) *searchIndex {
+	idx := &searchIndex{
+		postings: make(map[string][]posting),
+		stems:    make(map[string]string),
+		titles:   make([]string, len(items)),
+		bodies:   make([]string, len(items)),
+	}
+	copy(idx.bodies, bodies)
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i, item := range items {
+		weights := make(map[string]float64)
+		add := func(text string, weight float64) {
+			for _, word := range words(text) {
+				if stopWords[word] {
+					continue
+				}
+				s, ok := idx.stems[word]
+				if !ok {
+					s = stem(word)
+					idx.stems[word] = s
+				}
+				weights[s] += weight
+			}
+		}
+
+		if t.Kind() == reflect.Struct {
+			v := reflect.ValueOf(item.Meta)
+			for j := 0; j < t.NumField(); j++ {
+				f := t.Field(j)
+				if _, ok := ccfTag(f)["nosearch"]; ok || !f.IsExported() {
+					continue
+				}
+				weight := float64(metaWeight)
+				if metaKey(f) == "title" {
+					idx.titles[i] = strings.Join(metaStrings(v.Field(j)), " ")
+					weight = titleWeight
+				}
+				for _, text := range metaStrings(v.Field(j)) {
+					add(text, weight)
+				}
+			}
+		}
+		add(idx.bodies[i], bodyWeight)
+
+		for s, weight := range weights {
+			idx.postings[s] = append(idx.postings[s], posting{item: i, weight: weight})
+		}
+	}
+
+	for word := range idx.stems {
+		idx.words = append(idx.words, word)
+	}
+	slices.Sort(idx.words)
+
+	return idx
+}
+
+type searchHit struct {
+	item  int
+	score float64
+}
+
+// query returns the items containing every word of q, scored by BM25 without
+// length normalization, and the stems they were matched by. The last word of
+// q also matches words it is a prefix of, so that results can be shown while
+// typing.
+func (idx *searchIndex) query(q string) ([]searchHit, map[string]bool) {
+	var terms []string
+	for _, word := range words(q) {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+
+	matched := make(map[string]bool)
+	scores := make(map[int]float64)
+	counts := make(map[int]int)
+	for i, word := range terms {
+		stems := map[string]float64{stem(word): 1}
+		if i == len(terms)-1 && len([]rune(word)) >= 2 {
+			start, _ := slices.BinarySearch(idx.words, word)
+			for _, w := range idx.words[start:] {
+				if !strings.HasPrefix(w, word) {
+					break
+				}
+				if s := idx.stems[w]; stems[s] == 0 {
+					stems[s] = prefixWeight
+				}
+			}
+		}
+
+		best := make(map[int]float64)
+		for s, factor := range stems {
+			postings := idx.postings[s]
+			if len(postings) == 0 {
+				continue
+			}
+			matched[s] = true
+			n, df := float64(len(idx.titles)), float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for _, p := range postings {
+				tf := p.weight * (bm25K1 + 1) / (p.weight + bm25K1)
+				best[p.item] = max(best[p.item], factor*idf*tf)
+			}
+		}
+		for item, score := range best {
+			scores[item] += score
+			counts[item]++
+		}
+	}
+
+	var hits []searchHit
+	for item, count := range counts {
+		if count == len(terms) {
+			hits = append(hits, searchHit{item: item, score: scores[item]})
+		}
+	}
+
+	return hits, matched
+}
+
+// Search returns the visible items of type T matching the query q, best
+// first. Every word of q has to appear in the item's title, frontmatter text
+// fields or body, in any form: "configuring" matches "configured". The last
+// word also matches as a prefix.
+func Search[T any](q string) ([]SearchResult[T], error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	hits, matched := c.search.query(q)
+	now := time.Now()
+	results := make([]SearchResult[T], 0, len(hits))
+	for _, hit := range hits {
+		item := c.items[hit.item]
+		if !c.published(item, now) {
+			continue
+		}
+
+		body := c.search.bodies[hit.item]
+		if body == "" {
+			body = item.Summary
+		}
+		title := c.search.titles[hit.item]
+		results = append(results, SearchResult[T]{
+			Item:    c.view(item, now),
+			Score:   hit.score,
+			Title:   highlight(title, wordSpans(title), matched),
+			Snippet: snippet(body, matched),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b SearchResult[T]) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := b.Item.Date.Compare(a.Item.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Item.Slug, b.Item.Slug)
+	})
+
+	return results, nil
+}
+
+type searchResponse struct {
+	Query   string             `json:"query"`
+	Results []searchResultJSON `json:"results"`
+}
+
+type searchResultJSON struct {
+	URL     string  `json:"url"`
+	Slug    string  `json:"slug"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// SearchHandler serves the results of Search for the q query parameter as
+// JSON, with result URLs made by replacing :slug in pattern. The limit
+// parameter caps the number of results, SearchLimit by default. Mount it
+// next to the content routes, e.g.
+//
+//	e.GET("/search.json", content.SearchHandler[content.Doc]("/docs/:slug"))
+func SearchHandler[T any](pattern string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		q := c.QueryParam("q")
+		limit := SearchLimit
+		if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+			limit = l
+		}
+
+		results, err := Search[T](q)
+		if err != nil {
+			return err
+		}
+
+		resp := searchResponse{Query: q, Results: []searchResultJSON{}}
+		for _, r := range results[:min(limit, len(results))] {
+			resp.Results = append(resp.Results, searchResultJSON{
+				URL:     strings.ReplaceAll(pattern, ":slug", r.Item.Slug),
+				Slug:    r.Item.Slug,
+				Title:   r.Title,
+				Snippet: r.Snippet,
+				Score:   r.Score,
+			})
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+type span struct {
+	start, end int
+}
+
+// wordSpans returns the positions of the runs of letters and digits in s.
+func wordSpans(s string) []span {
+	var spans []span
+	start := -1
+	for i, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+		} else if start >= 0 {
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(s)})
+	}
+
+	return spans
+}
+
+// words returns the lowercased words of s.
+func words(s string) []string {
+	spans := wordSpans(s)
+	words := make([]string, len(spans))
+	for i, sp := range spans {
+		words[i] = strings.ToLower(s[sp.start:sp.end])
+	}
+
+	return words
+}
+
+// snippet returns about snippetWords words of text starting shortly before
+// the first word matching one of stems, highlighted.
+func snippet(text string, stems map[string]bool) string {
+	spans := wordSpans(text)
+	first := 0
+	for i, sp := range spans {
+		if stems[stem(strings.ToLower(text[sp.start:sp.end]))] {
+			first = i
+			break
+		}
+	}
+
+	from := max(0, first-snippetWords/4)
+	to := min(len(spans), from+snippetWords)
+	from = max(0, to-snippetWords)
+
+	return highlight(text, spans[from:to], stems)
+}
+
+// highlight returns the HTML of the text covered by spans, with the words
+// matching one of stems in <mark> elements. It starts or ends with an
+// ellipsis if the spans do not reach the start or end of text.
+func highlight(text string, spans []span, stems map[string]bool) string {
+	if len(spans) == 0 {
+		return html.EscapeString(text)
+	}
+
+	var b strings.Builder
+	pos := spans[0].start
+	if len(wordSpans(text[:pos])) > 0 {
+		b.WriteString("…")
+	}
+	for _, sp := range spans {
+		b.WriteString(html.EscapeString(text[pos:sp.start]))
+		word := html.EscapeString(text[sp.start:sp.end])
+		if stems[stem(strings.ToLower(text[sp.start:sp.end]))] {
+			word = "<mark>" + word + "</mark>"
+		}
+		b.WriteString(word)
+		pos = sp.end
+	}
+	if last := wordSpans(text[pos:]); len(last) > 0 {
+		b.WriteString("…")
+	} else {
+		b.WriteString(html.EscapeString(text[pos:]))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package content
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Nav is the position of an item within an ordered list, such as a sorted
+// collection or a series, for "part 2 of 5" headers and prev/next links.
+type Nav[E any] struct {
+	Items []E
+	// Index is the 0-based position of the item in Items.
+	Index int
+	// Prev and Next are the neighbouring items, or nil at either end.
+	Prev *E
+	Next *E
+}
+
+// Position returns the 1-based position of the item.
+func (n Nav[E]) Position() int {
+	return n.Index + 1
+}
+
+// Total returns the number of items in the list.
+func (n Nav[E]) Total() int {
+	return len(n.Items)
+}
+
+// Neighbors returns the position within items of the first item for which
+// match returns true, and reports whether there is one.
+//
+//	nav, ok := content.Neighbors(posts, func(p PostItem) bool { return p.Slug == slug })
+func Neighbors[E any](items []E, match func(E) bool) (Nav[E], bool) {
+	i := slices.IndexFunc(items, match)
+	if i < 0 {
+		return Nav[E]{}, false
+	}
+
+	nav := Nav[E]{Items: items, Index: i}
+	if i > 0 {
+		nav.Prev = &items[i-1]
+	}
+	if i < len(items)-1 {
+		nav.Next = &items[i+1]
+	}
+
+	return nav, true
+}
+
+// indexSeries groups the items of a collection by the slug of their series,
+// ordered by SeriesPart, then Date, then Slug. Items without a SeriesPart
+// come after those with one.
+func indexSeries[T any](items []ContentItem[T]) map[string][]int {
+	series := make(map[string][]int)
+	for i, item := range items {
+		if slug := slugify(item.Series); slug != "" {
+			series[slug] = append(series[slug], i)
+		}
+	}
+
+	for _, members := range series {
+		slices.SortStableFunc(members, func(a, b int) int {
+			x, y := items[a], items[b]
+			if (x.SeriesPart == 0) != (y.SeriesPart == 0) {
+				if x.SeriesPart == 0 {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Or(
+				cmp.Compare(x.SeriesPart, y.SeriesPart),
+				x.Date.Compare(y.Date),
+				cmp.Compare(x.Slug, y.Slug),
+			)
+		})
+	}
+
+	return series
+}
+
+// Series returns the visible items of the named series, in order.
+func Series[T any](name string) ([]ContentItem[T], error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	return c.seriesItems(slugify(name), time.Now()), nil
+}
+
+// SeriesOf returns the visible items of the series the item with the given
+// slug belongs to, in order, or nil if it is not part of a series. The error
+// matches ErrNotFound if there is no such item.
+func SeriesOf[T any](slug string) ([]ContentItem[T], error) {
+	item, err := GetItem[T](slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return Series[T](item.Series)
+}
+
+func (c *collection[T]) seriesItems(slug string, now time.Time) []ContentItem[T] {
+	var items []ContentItem[T]
+	for _, i := range c.series[slug] {
+		if c.published(c.items[i], now) {
+			items = append(items, c.view(c.items[i], now))
+		}
+	}
+	return items
+}
@@ -0,0 +1,31 @@
+package content
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// slugify lowercases s and joins its runs of letters and digits with dashes.
+// Accents are stripped; other non-ASCII letters are kept as they are.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent left over from decomposition
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			dash = true
+		}
+	}
+
+	return norm.NFC.String(b.String())
+}
@@ -0,0 +1,218 @@
+package content
+
+import "strings"
+
+// stem reduces an English word to its stem with the Porter algorithm, so
+// that "connected", "connecting" and "connections" all become "connect".
+// Words that are not lowercase ASCII letters are returned unchanged.
+func stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.replaceFirst(step2Suffixes, 0)
+	s.replaceFirst(step3Suffixes, 0)
+	s.step4()
+	s.step5()
+
+	return string(s.b)
+}
+
+type stemmer struct {
+	b []byte
+}
+
+// cons reports whether b[i] is a consonant. Y is one unless it follows a
+// consonant.
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// measure returns the number of vowel-consonant sequences in b[:n], the m
+// of [C](VC){m}[V].
+func (s *stemmer) measure(n int) int {
+	m, i := 0, 0
+	for i < n && s.cons(i) {
+		i++
+	}
+	for i < n {
+		for i < n && !s.cons(i) {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		for i < n && s.cons(i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func (s *stemmer) hasVowel(n int) bool {
+	for i := 0; i < n; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleCons reports whether b[:n] ends with a double consonant.
+func (s *stemmer) doubleCons(n int) bool {
+	return n >= 2 && s.b[n-1] == s.b[n-2] && s.cons(n-1)
+}
+
+// cvc reports whether b[:n] ends consonant-vowel-consonant, with the last
+// consonant not w, x or y, as in "hop" but not "snow".
+func (s *stemmer) cvc(n int) bool {
+	if n < 3 || !s.cons(n-3) || s.cons(n-2) || !s.cons(n-1) {
+		return false
+	}
+	c := s.b[n-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+// stemLen returns the length of b without suffix, or -1 if b does not end
+// with it.
+func (s *stemmer) stemLen(suffix string) int {
+	if !strings.HasSuffix(string(s.b), suffix) {
+		return -1
+	}
+	return len(s.b) - len(suffix)
+}
+
+func (s *stemmer) set(n int, suffix string) {
+	s.b = append(s.b[:n], suffix...)
+}
+
+type suffixRule struct {
+	suffix, replacement string
+}
+
+// replaceFirst applies the first rule whose suffix b ends with, if the
+// measure of the remaining stem is above min.
+func (s *stemmer) replaceFirst(rules []suffixRule, min int) {
+	for _, r := range rules {
+		if n := s.stemLen(r.suffix); n >= 0 {
+			if s.measure(n) > min {
+				s.set(n, r.replacement)
+			}
+			return
+		}
+	}
+}
+
+// step1a removes plurals: caresses => caress, ponies => poni, cats => cat.
+func (s *stemmer) step1a() {
+	switch {
+	case s.stemLen("sses") >= 0, s.stemLen("ies") >= 0:
+		s.b = s.b[:len(s.b)-2]
+	case s.stemLen("ss") >= 0:
+	case s.stemLen("s") >= 0:
+		s.b = s.b[:len(s.b)-1]
+	}
+}
+
+// step1b removes -ed and -ing: agreed => agree, hopping => hop,
+// hoping => hope.
+func (s *stemmer) step1b() {
+	if n := s.stemLen("eed"); n >= 0 {
+		if s.measure(n) > 0 {
+			s.b = s.b[:len(s.b)-1]
+		}
+		return
+	}
+
+	n := s.stemLen("ed")
+	if n < 0 {
+		n = s.stemLen("ing")
+	}
+	if n < 0 || !s.hasVowel(n) {
+		return
+	}
+	s.b = s.b[:n]
+
+	switch {
+	case s.stemLen("at") >= 0, s.stemLen("bl") >= 0, s.stemLen("iz") >= 0:
+		s.b = append(s.b, 'e')
+	case s.doubleCons(n):
+		if c := s.b[n-1]; c != 'l' && c != 's' && c != 'z' {
+			s.b = s.b[:n-1]
+		}
+	case s.measure(n) == 1 && s.cvc(n):
+		s.b = append(s.b, 'e')
+	}
+}
+
+// step1c turns a final y into i after a vowel: happy => happi.
+func (s *stemmer) step1c() {
+	if n := s.stemLen("y"); n >= 0 && s.hasVowel(n) {
+		s.b[n] = 'i'
+	}
+}
+
+var step2Suffixes = []suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+	{"logi", "log"},
+}
+
+var step3Suffixes = []suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+// step4 removes suffixes from longer stems: adjustment => adjust.
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes {
+		n := s.stemLen(suffix)
+		if n < 0 {
+			continue
+		}
+		if suffix == "ion" && (n == 0 || (s.b[n-1] != 's' && s.b[n-1] != 't')) {
+			return
+		}
+		if s.measure(n) > 1 {
+			s.b = s.b[:n]
+		}
+		return
+	}
+}
+
+// step5 removes a final e and a double l from longer stems:
+// probate => probat, controll => control.
+func (s *stemmer) step5() {
+	if n := s.stemLen("e"); n >= 0 {
+		if m := s.measure(n); m > 1 || (m == 1 && !s.cvc(n)) {
+			s.b = s.b[:n]
+		}
+	}
+	if n := len(s.b); s.b[n-1] == 'l' && s.doubleCons(n) && s.measure(n) > 1 {
+		s.b = s.b[:n-1]
+	}
+}
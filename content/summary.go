@@ -0,0 +1,112 @@
+package content
+
+import (
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+)
+
+const (
+	// moreSeparator marks the end of an item's excerpt when on a line of its own.
+	moreSeparator = "<!--more-->"
+	// summaryWords is the length of a summary taken from the body text.
+	summaryWords = 70
+	// wordsPerMinute is the reading speed used for ReadingTime.
+	wordsPerMinute = 200
+)
+
+type summary struct {
+	// body is the plain text of the whole item, for the search index.
+	body        string
+	text        string
+	excerpt     string
+	wordCount   int
+	readingTime time.Duration
+}
+
+// summarize computes the summary fields of an item. A <!--more--> separator
+// takes precedence for the excerpt, and description for the summary text.
+func (d *document) summarize(markdown goldmark.Markdown, description string) (summary, error) {
+	body := plainText(d.root, d.source)
+	s := summary{body: body, wordCount: len(strings.Fields(body))}
+	s.readingTime = time.Duration(math.Ceil(float64(s.wordCount)/wordsPerMinute)) * time.Minute
+
+	var err error
+	if intro, ok := d.beforeMore(); ok {
+		s.excerpt, err = d.renderNodes(markdown, intro)
+		if err != nil {
+			return s, err
+		}
+
+		var text []string
+		for _, n := range intro {
+			text = append(text, plainText(n, d.source))
+		}
+		s.text = strings.Join(text, " ")
+	} else if description != "" {
+		s.excerpt = "<p>" + html.EscapeString(description) + "</p>"
+	} else if p := d.firstParagraph(); p != nil {
+		s.excerpt, err = d.renderNodes(markdown, []ast.Node{p})
+		if err != nil {
+			return s, err
+		}
+	}
+
+	switch {
+	case description != "":
+		s.text = description
+	case s.text == "":
+		s.text = truncateWords(body, summaryWords)
+	}
+
+	return s, nil
+}
+
+// beforeMore returns the top-level nodes preceding a <!--more--> separator.
+func (d *document) beforeMore() ([]ast.Node, bool) {
+	var nodes []ast.Node
+	for n := d.root.FirstChild(); n != nil; n = n.NextSibling() {
+		if block, ok := n.(*ast.HTMLBlock); ok && isMoreSeparator(block, d.source) {
+			return nodes, true
+		}
+		nodes = append(nodes, n)
+	}
+
+	return nil, false
+}
+
+func isMoreSeparator(block *ast.HTMLBlock, source []byte) bool {
+	var raw strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		raw.Write(line.Value(source))
+	}
+
+	return strings.ReplaceAll(strings.TrimSpace(raw.String()), " ", "") == moreSeparator
+}
+
+func (d *document) firstParagraph() ast.Node {
+	for n := d.root.FirstChild(); n != nil; n = n.NextSibling() {
+		if n.Kind() == ast.KindParagraph {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// truncateWords returns the first n words of s, followed by an ellipsis if
+// any were cut.
+func truncateWords(s string, n int) string {
+	words := strings.Fields(s)
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:n], " ") + "…"
+}
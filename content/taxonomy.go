@@ -0,0 +1,134 @@
+package content
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// Term is a value of a taxonomy field, such as a single tag.
+type Term struct {
+	// Name is the term as first written in the frontmatter.
+	Name string
+	// Slug identifies the term in URLs and ByTerm lookups.
+	Slug string
+	// Count is the number of visible items with the term.
+	Count int
+}
+
+// taxonomy indexes the items of a collection by the terms of one field.
+type taxonomy struct {
+	names map[string]string // term slug => display name
+	items map[string][]int  // term slug => item indexes
+}
+
+// indexTaxonomies indexes the fields of T tagged `ccf:"taxonomy"` by their
+// frontmatter key. The fields must be strings or slices of strings.
+func indexTaxonomies[T any](items []ContentItem[T]) map[string]*taxonomy {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	taxonomies := make(map[string]*taxonomy)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := ccfTag(f)["taxonomy"]; !ok {
+			continue
+		}
+
+		tax := &taxonomy{
+			names: make(map[string]string),
+			items: make(map[string][]int),
+		}
+		for j, item := range items {
+			for _, name := range metaStrings(reflect.ValueOf(item.Meta).Field(i)) {
+				slug := slugify(name)
+				if slug == "" {
+					continue
+				}
+				if _, ok := tax.names[slug]; !ok {
+					tax.names[slug] = name
+				}
+				if n := len(tax.items[slug]); n == 0 || tax.items[slug][n-1] != j {
+					tax.items[slug] = append(tax.items[slug], j)
+				}
+			}
+		}
+		taxonomies[metaKey(f)] = tax
+	}
+
+	return taxonomies
+}
+
+func (c *collection[T]) taxonomy(name string) (*taxonomy, error) {
+	tax, ok := c.taxonomies[name]
+	if !ok {
+		return nil, fmt.Errorf("%v has no taxonomy %q, tag a field with `ccf:\"taxonomy\"`", reflect.TypeOf((*T)(nil)).Elem(), name)
+	}
+
+	return tax, nil
+}
+
+// Terms returns the terms used by visible items of type T in the given
+// taxonomy, most used first.
+func Terms[T any](taxonomy string) ([]Term, error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	tax, err := c.taxonomy(taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var terms []Term
+	for slug, indexes := range tax.items {
+		count := 0
+		for _, i := range indexes {
+			if c.published(c.items[i], now) {
+				count++
+			}
+		}
+		if count > 0 {
+			terms = append(terms, Term{Name: tax.names[slug], Slug: slug, Count: count})
+		}
+	}
+
+	slices.SortFunc(terms, func(a, b Term) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Slug, b.Slug)
+	})
+
+	return terms, nil
+}
+
+// ByTerm returns the visible items of type T that have term in the given
+// taxonomy. The term is matched by its slug, so "Go", "go" and "GO" are the same.
+func ByTerm[T any](taxonomy, term string) ([]ContentItem[T], error) {
+	c, err := getCollection[T]()
+	if err != nil {
+		return nil, err
+	}
+
+	tax, err := c.taxonomy(taxonomy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var items []ContentItem[T]
+	for _, i := range tax.items[slugify(term)] {
+		if c.published(c.items[i], now) {
+			items = append(items, c.view(c.items[i], now))
+		}
+	}
+
+	return items, nil
+}
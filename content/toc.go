@@ -0,0 +1,101 @@
+package content
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// Heading is an entry in a content item's table of contents.
+// Children holds the headings nested below it.
+type Heading struct {
+	Level    int
+	Text     string
+	ID       string
+	Children []Heading
+}
+
+// headingIDs generates slug-style heading ids, deduplicated within a document.
+type headingIDs struct {
+	values map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{values: make(map[string]bool)}
+}
+
+// Generate implements parser.IDs.
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slugify(string(value))
+	if base == "" {
+		base = "heading"
+	}
+
+	id := base
+	for i := 1; s.values[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	s.values[id] = true
+
+	return []byte(id)
+}
+
+// Put implements parser.IDs.
+func (s *headingIDs) Put(value []byte) {
+	s.values[string(value)] = true
+}
+
+// headings collects the document's headings in order. When anchors is set, a
+// permalink to each heading is appended to it.
+func (d *document) headings(anchors bool) []Heading {
+	var headings []Heading
+	_ = ast.Walk(d.root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+
+		var id string
+		if v, ok := heading.AttributeString("id"); ok {
+			id = string(v.([]byte))
+		}
+
+		headings = append(headings, Heading{
+			Level: heading.Level,
+			Text:  plainText(heading, d.source),
+			ID:    id,
+		})
+
+		if anchors && id != "" {
+			link := ast.NewLink()
+			link.Destination = []byte("#" + id)
+			link.SetAttributeString("class", []byte(headingAnchorClass))
+			link.AppendChild(link, ast.NewString([]byte("#")))
+			heading.AppendChild(heading, link)
+		}
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return headings
+}
+
+// nestHeadings nests each heading under the closest preceding heading of a
+// lower level.
+func nestHeadings(flat []Heading) []Heading {
+	var nested []Heading
+	for i := 0; i < len(flat); {
+		h := flat[i]
+
+		j := i + 1
+		for j < len(flat) && flat[j].Level > h.Level {
+			j++
+		}
+
+		h.Children = nestHeadings(flat[i+1 : j])
+		nested = append(nested, h)
+		i = j
+	}
+
+	return nested
+}
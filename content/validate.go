@@ -0,0 +1,210 @@
+package content
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validator can be implemented by a content type to check its frontmatter
+// after decoding. Errors are reported with the path of the file.
+type Validator interface {
+	Validate() error
+}
+
+// FrontmatterError is a problem with the frontmatter of a content file.
+// LoadItems collects them for every file and returns them joined.
+type FrontmatterError struct {
+	Path string
+	// Line is the line of the offending key in the file, or 0 if unknown.
+	Line int
+	Err  error
+}
+
+func (e *FrontmatterError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FrontmatterError) Unwrap() error {
+	return e.Err
+}
+
+// validateFrontmatter checks decoded frontmatter against its content type:
+// keys must be known, required fields set, and Validate must pass. Unknown
+// keys are only logged unless rejectUnknown is set.
+func validateFrontmatter(path string, content []byte, meta any, keys map[string]any, rejectUnknown bool) []error {
+	var errs []error
+
+	known, all := frontmatterKeys(reflect.TypeOf(meta).Elem())
+	builtin, _ := frontmatterKeys(reflect.TypeFor[builtinFrontmatter]())
+	for _, key := range slices.Sorted(maps.Keys(keys)) {
+		if all || known[key] || builtin[key] {
+			continue
+		}
+
+		line := keyLine(content, key)
+		if !rejectUnknown {
+			slog.Warn("unknown frontmatter key", "path", path, "line", line, "key", key)
+			continue
+		}
+		errs = append(errs, &FrontmatterError{Path: path, Line: line, Err: fmt.Errorf("unknown key %q", key)})
+	}
+
+	v := reflect.ValueOf(meta).Elem()
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if _, ok := ccfTag(f)["required"]; ok && f.IsExported() && v.Field(i).IsZero() {
+				errs = append(errs, &FrontmatterError{Path: path, Err: fmt.Errorf("missing required key %q", metaKey(f))})
+			}
+		}
+	}
+
+	if validator, ok := meta.(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			errs = append(errs, &FrontmatterError{Path: path, Err: err})
+		}
+	}
+
+	return errs
+}
+
+// frontmatterKeys returns the frontmatter keys struct type t decodes: the
+// names in its yaml, json and toml tags, or the lowercased field name for
+// fields without a yaml tag, as yaml.v3 does. Keys are matched exactly, since
+// yaml.v3 does, so "Title" is not a known key for `yaml:"title"`. all is true
+// if t accepts every key through an inline map.
+func frontmatterKeys(t reflect.Type) (keys map[string]bool, all bool) {
+	keys = make(map[string]bool)
+	if t.Kind() != reflect.Struct {
+		return keys, true
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		yamlName, yamlOpts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if f.Anonymous || strings.Contains(yamlOpts, "inline") {
+			if f.Type.Kind() == reflect.Map {
+				return keys, true
+			}
+			embedded, all := frontmatterKeys(f.Type)
+			if all {
+				return keys, true
+			}
+			maps.Copy(keys, embedded)
+			continue
+		}
+
+		if yamlName == "" {
+			keys[strings.ToLower(f.Name)] = true
+		}
+		for _, name := range []string{yamlName, tagName(f, "json"), tagName(f, "toml")} {
+			if name != "" && name != "-" {
+				keys[name] = true
+			}
+		}
+	}
+
+	return keys, false
+}
+
+func tagName(f reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+	return name
+}
+
+// decodeLine matches the line yaml.v3 and toml prefix their errors with.
+var decodeLine = regexp.MustCompile(`^(yaml: )?(?:line|Near line) (\d+)(?: \(last key parsed '[^']*'\))?: `)
+
+// decodeErrors returns a FrontmatterError for each problem reported by err,
+// the error of decoding frontmatter that starts after line offset of content
+// into a t. Lines reported by the decoder are made relative to the file, and
+// unrecognized dates are located with keyLine.
+func decodeErrors(path string, content []byte, offset int, t reflect.Type, err error) []error {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	var errs []error
+	for _, msg := range messages {
+		m := decodeLine.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		line, _ := strconv.Atoi(m[2])
+		errs = append(errs, &FrontmatterError{Path: path, Line: offset + line, Err: errors.New(m[1] + msg[len(m[0]):])})
+	}
+	if len(errs) == len(messages) {
+		return errs
+	}
+
+	line := 0
+	var dateErr *dateError
+	if errors.As(err, &dateErr) {
+		line = dateLine(content, t, dateErr.value)
+	}
+	return []error{&FrontmatterError{Path: path, Line: line, Err: err}}
+}
+
+// dateLine returns the line of the Date field of struct type t holding value
+// in content, or 0 if there is none.
+func dateLine(content []byte, t reflect.Type, value string) int {
+	if t.Kind() != reflect.Struct {
+		return 0
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type != reflect.TypeFor[Date]() && f.Type != reflect.TypeFor[*Date]() {
+			continue
+		}
+		for _, key := range []string{metaKey(f), tagName(f, "json"), tagName(f, "toml")} {
+			if line := keyLine(content, key); key != "" && line > 0 && strings.Contains(lines[line-1], value) {
+				return line
+			}
+		}
+	}
+
+	return 0
+}
+
+// keyLine returns the line of the first "key:" or "key =" in content, quoted
+// or not, or 0 if there is none.
+func keyLine(content []byte, key string) int {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		for _, quoted := range []string{key, `"` + key + `"`, "'" + key + "'"} {
+			rest, ok := strings.CutPrefix(text, quoted)
+			if !ok {
+				continue
+			}
+			rest = strings.TrimSpace(rest)
+			if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "=") {
+				return line
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,124 @@
+package content
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// watchInterval is how often a watched content directory is checked for changes.
+var watchInterval = time.Second
+
+// watchers holds the running watcher of each type.
+var (
+	watchersMu sync.Mutex
+	watchers   = make(map[reflect.Type]*watcher)
+)
+
+// watcher is a running watcher. Closing stop ends it, and done is closed
+// once it has, after any reload in progress.
+type watcher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// DevMode reports whether content should be read from disk and reloaded on
+// change. It is enabled by setting CCF_DEV=true.
+func DevMode() bool {
+	return os.Getenv("CCF_DEV") == "true"
+}
+
+// stopWatching stops the watcher of type T, if any, and waits for it to end
+// so that a reload in progress cannot replace items loaded after it.
+func stopWatching[T any]() {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+
+	if w, ok := watchers[t]; ok {
+		close(w.stop)
+		<-w.done
+		delete(watchers, t)
+	}
+}
+
+// watch polls dirName for changes and reloads the items of type T with load
+// when something changes. A failed reload keeps the previously loaded items.
+func watch[T any](fsys fs.FS, dirName string, cfg loadConfig, load func(fs.FS, string, loadConfig) error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	w := &watcher{stop: make(chan struct{}), done: make(chan struct{})}
+
+	watchersMu.Lock()
+	watchers[t] = w
+	watchersMu.Unlock()
+
+	last, err := fingerprintDir(fsys, dirName)
+	if err != nil {
+		slog.Warn("failed to fingerprint content directory", "dir", dirName, "err", err)
+	}
+
+	slog.Info("Watching content", "type", t, "dir", cfg.watchDir+"/"+dirName)
+	interval := watchInterval
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+
+			fp, err := fingerprintDir(fsys, dirName)
+			if err != nil {
+				slog.Warn("failed to fingerprint content directory", "dir", dirName, "err", err)
+				continue
+			}
+			if fp == last {
+				continue
+			}
+			last = fp
+
+			slog.Info("Content changed, reloading", "type", t, "dir", dirName)
+			if err := load(fsys, dirName, cfg); err != nil {
+				slog.Error("failed to reload content", "type", t, "err", err)
+			}
+		}
+	}()
+}
+
+// fingerprintDir hashes the name, size and modification time of every file
+// under dirName.
+func fingerprintDir(fsys fs.FS, dirName string) (uint64, error) {
+	h := fnv.New64a()
+	err := fs.WalkDir(fsys, dirName, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	return h.Sum64(), nil
+}
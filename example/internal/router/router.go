@@ -14,6 +14,12 @@ func RegisterRoutes(e *echo.Echo) {
 	e.POST("/posts", PostsPOST)
 }
 
+// StaticRoutes lists the GET routes without parameters, e.g. for a sitemap.
+var StaticRoutes = []string{
+	"/",
+	"/posts",
+}
+
 // BlogSlugGET handles GET requests to /blog/:slug
 func BlogSlugGET(c echo.Context) error {
 	result, err := pages.BlogSlugGET(c, c.Param("slug"))
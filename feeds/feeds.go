@@ -0,0 +1,218 @@
+// Package feeds turns content collections into RSS 2.0, Atom and JSON Feed
+// documents.
+package feeds
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.quinn.io/ccf/content"
+)
+
+// Feed is a feed document, independent of its format.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the absolute URL of the page the feed is about.
+	Link string
+	// FeedURL is the absolute URL of the feed itself.
+	FeedURL  string
+	Author   string
+	Language string
+	Updated  time.Time
+	Items    []Item
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	// ID is a unique, permanent identifier. It defaults to Link.
+	ID    string
+	Title string
+	// Link is the absolute URL of the item.
+	Link    string
+	Summary string
+	// HTML is the rendered content, with URLs made absolute.
+	HTML       string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+// Config describes how a collection of type T maps to a feed.
+//
+// Item fields are filled from the frontmatter fields tagged `feed:"title"`,
+// `feed:"author"`, `feed:"categories"` and `feed:"updated"`, or else from
+// fields with the title and author keys. The summary and HTML come from
+// the ContentItem and the publication date from its Date.
+type Config[T any] struct {
+	Title       string
+	Description string
+	Author      string
+	Language    string
+	// BaseURL is the absolute URL of the site, e.g. "https://example.com".
+	BaseURL string
+	// Path is the URL path of the collection, e.g. "/blog/". An item's link
+	// is BaseURL + Path + slug.
+	Path string
+	// Limit is the maximum number of items, newest first. 0 means 20 and
+	// a negative limit means no limit.
+	Limit int
+	// Map adjusts each item after the defaults are applied.
+	Map func(item content.ContentItem[T], entry *Item)
+}
+
+// DefaultLimit is the number of items in a feed when Config.Limit is 0.
+const DefaultLimit = 20
+
+// New builds a feed from the visible items of type T, newest first.
+func New[T any](cfg Config[T]) (*Feed, error) {
+	limit := cfg.Limit
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	items, err := content.Query[T]().
+		SortFunc(func(a, b content.ContentItem[T]) int { return b.Date.Compare(a.Date) }).
+		Limit(limit).
+		Items()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed items: %w", err)
+	}
+
+	return FromItems(items, cfg), nil
+}
+
+// FromItems builds a feed from items, in the given order.
+func FromItems[T any](items []content.ContentItem[T], cfg Config[T]) *Feed {
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	feed := &Feed{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		Link:        base + cfg.Path,
+		Author:      cfg.Author,
+		Language:    cfg.Language,
+	}
+
+	fields := feedFields(reflect.TypeOf((*T)(nil)).Elem())
+	for _, item := range items {
+		link := base + cfg.Path + item.Slug
+		entry := Item{
+			ID:        link,
+			Link:      link,
+			Summary:   item.Summary,
+			HTML:      absoluteURLs(item.HTML, base, link),
+			Published: item.Date,
+		}
+		fields.apply(reflect.ValueOf(item.Meta), &entry)
+		if entry.Updated.IsZero() {
+			entry.Updated = entry.Published
+		}
+		if cfg.Map != nil {
+			cfg.Map(item, &entry)
+		}
+
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+		feed.Items = append(feed.Items, entry)
+	}
+
+	return feed
+}
+
+// fieldRoles maps feed item fields to the index of the frontmatter field
+// they are read from.
+type fieldRoles map[string]int
+
+// feedFields finds the fields of struct type t that feed item fields are
+// read from.
+func feedFields(t reflect.Type) fieldRoles {
+	roles := make(fieldRoles)
+	if t.Kind() != reflect.Struct {
+		return roles
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if role := f.Tag.Get("feed"); role != "" && f.IsExported() {
+			roles[role] = i
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		if _, ok := roles[key]; !ok && f.IsExported() && (key == "title" || key == "author") {
+			roles[key] = i
+		}
+	}
+
+	return roles
+}
+
+func (roles fieldRoles) apply(meta reflect.Value, entry *Item) {
+	for role, i := range roles {
+		v := meta.Field(i)
+		switch role {
+		case "title":
+			entry.Title = fmt.Sprint(v.Interface())
+		case "author":
+			entry.Author = fmt.Sprint(v.Interface())
+		case "categories":
+			if v.Kind() == reflect.Slice {
+				for j := 0; j < v.Len(); j++ {
+					entry.Categories = append(entry.Categories, fmt.Sprint(v.Index(j).Interface()))
+				}
+			}
+		case "updated":
+			switch t := v.Interface().(type) {
+			case time.Time:
+				entry.Updated = t
+			case content.Date:
+				entry.Updated = t.Time
+			}
+		}
+	}
+}
+
+// urlAttr matches the href, src and srcset attributes of HTML tags.
+var urlAttr = regexp.MustCompile(`\s(href|src|srcset)="([^"]*)"`)
+
+// absoluteURLs rewrites root-relative URLs in html to start with base, and
+// fragment links to point into the item at link, as feed readers show
+// content outside of the site.
+func absoluteURLs(html, base, link string) string {
+	return urlAttr.ReplaceAllStringFunc(html, func(m string) string {
+		attr := urlAttr.FindStringSubmatch(m)
+		value := attr[2]
+		if attr[1] == "srcset" {
+			candidates := strings.Split(value, ",")
+			for i, c := range candidates {
+				candidates[i] = absoluteURL(strings.TrimSpace(c), base, link)
+			}
+			value = strings.Join(candidates, ", ")
+		} else {
+			value = absoluteURL(value, base, link)
+		}
+		return fmt.Sprintf(` %s="%s"`, attr[1], value)
+	})
+}
+
+func absoluteURL(url, base, link string) string {
+	switch {
+	case strings.HasPrefix(url, "//"):
+		return url
+	case strings.HasPrefix(url, "/"):
+		return base + url
+	case strings.HasPrefix(url, "#"):
+		return link + url
+	}
+	return url
+}
@@ -0,0 +1,103 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"go.quinn.io/ccf/content"
+)
+
+type Post struct {
+	Title  string       `yaml:"title"`
+	Date   content.Date `yaml:"date"`
+	Tags   []string     `yaml:"tags" feed:"categories"`
+	Writer string       `yaml:"writer" feed:"author"`
+}
+
+func loadPosts(t *testing.T) {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"posts/old.md": &fstest.MapFile{Data: []byte("---\ntitle: Old\ndate: 2024-01-01\n---\nOld post.")},
+		"posts/new.md": &fstest.MapFile{Data: []byte("---\ntitle: New\ndate: 2024-02-01\ntags: [go]\nwriter: Jane\n---\n" +
+			"See [the other one](/blog/old), [below](#more) and ![pic](/img/a.png).\n\n## More")},
+	}
+	if err := content.LoadItems[Post](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+}
+
+var testConfig = Config[Post]{
+	Title:   "Blog",
+	BaseURL: "https://example.com/",
+	Path:    "/blog/",
+}
+
+func TestNew(t *testing.T) {
+	loadPosts(t)
+
+	feed, err := New(testConfig)
+	if err != nil {
+		t.Fatalf("Failed to build feed: %v", err)
+	}
+
+	if len(feed.Items) != 2 || feed.Items[0].Title != "New" {
+		t.Fatalf("Expected 2 items, newest first, got %+v", feed.Items)
+	}
+
+	item := feed.Items[0]
+	if item.Link != "https://example.com/blog/new" || item.Author != "Jane" || len(item.Categories) != 1 {
+		t.Errorf("Unexpected item fields: %+v", item)
+	}
+	for _, want := range []string{
+		`href="https://example.com/blog/old"`,
+		`href="https://example.com/blog/new#more"`,
+		`src="https://example.com/img/a.png"`,
+	} {
+		if !strings.Contains(item.HTML, want) {
+			t.Errorf("Expected %s in %s", want, item.HTML)
+		}
+	}
+	if !feed.Updated.Equal(item.Published) {
+		t.Errorf("Expected feed to be updated at %v, got %v", item.Published, feed.Updated)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	loadPosts(t)
+
+	e := echo.New()
+	Register(e, "/blog/", testConfig)
+
+	for path, contentType := range map[string]string{
+		"/blog/rss.xml":   "application/rss+xml",
+		"/blog/atom.xml":  "application/atom+xml",
+		"/blog/feed.json": "application/feed+json",
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), contentType) {
+			t.Errorf("Unexpected response for %s: %d %s", path, rec.Code, rec.Header().Get(echo.HeaderContentType))
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), "https://example.com"+path) {
+			t.Errorf("Expected self link in %s", path)
+		}
+
+		var err error
+		if strings.HasSuffix(path, ".json") {
+			err = json.Unmarshal(rec.Body.Bytes(), new(map[string]any))
+		} else {
+			err = xml.Unmarshal(rec.Body.Bytes(), new(struct{}))
+		}
+		if err != nil {
+			t.Errorf("Invalid %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package feeds
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Content string     `xml:"xmlns:content,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          *atomLink `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description,omitempty"`
+	Content     string   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	ID          string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// RSS encodes the feed as RSS 2.0.
+func (f *Feed) RSS() ([]byte, error) {
+	doc := rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Content: "http://purl.org/rss/1.0/modules/content/",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			Language:      f.Language,
+			LastBuildDate: rssDate(f.Updated),
+		},
+	}
+	if f.FeedURL != "" {
+		doc.Channel.Self = &atomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, item := range f.Items {
+		author := item.Author
+		if author == "" {
+			author = f.Author
+		}
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        rssGUID{ID: item.ID, IsPermaLink: item.ID == item.Link},
+			PubDate:     rssDate(item.Published),
+			Creator:     author,
+			Categories:  item.Categories,
+			Description: item.Summary,
+			Content:     item.HTML,
+		})
+	}
+
+	return marshalXML(doc)
+}
+
+func rssDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	Lang     string      `xml:"xml:lang,attr,omitempty"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomAuthor `xml:"author"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom encodes the feed as Atom 1.0.
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		NS:       "http://www.w3.org/2005/Atom",
+		Lang:     f.Language,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.Link,
+		Updated:  atomDate(f.updated()),
+		Links:    []atomLink{{Href: f.Link, Rel: "alternate", Type: "text/html"}},
+	}
+	if f.FeedURL != "" {
+		doc.ID = f.FeedURL
+		doc.Links = append(doc.Links, atomLink{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+	if f.Author != "" {
+		doc.Author = &atomAuthor{Name: f.Author}
+	}
+
+	for _, item := range f.Items {
+		entry := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomLink{Href: item.Link, Rel: "alternate", Type: "text/html"},
+			Published: atomDate(item.Published),
+			Updated:   atomDate(item.Updated),
+		}
+		if entry.Updated == "" {
+			entry.Updated = doc.Updated
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		for _, c := range item.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+		if item.HTML != "" {
+			entry.Content = &atomText{Type: "html", Body: item.HTML}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshalXML(doc)
+}
+
+func atomDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// updated returns the feed's Updated time, or now if it has none, as Atom
+// requires one.
+func (f *Feed) updated() time.Time {
+	if f.Updated.IsZero() {
+		return time.Now()
+	}
+	return f.Updated
+}
+
+func marshalXML(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+type jsonFeed struct {
+	Version     string       `json:"version"`
+	Title       string       `json:"title"`
+	HomePageURL string       `json:"home_page_url,omitempty"`
+	FeedURL     string       `json:"feed_url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Language    string       `json:"language,omitempty"`
+	Authors     []jsonAuthor `json:"authors,omitempty"`
+	Items       []jsonItem   `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+}
+
+type jsonItem struct {
+	ID            string       `json:"id"`
+	URL           string       `json:"url,omitempty"`
+	Title         string       `json:"title,omitempty"`
+	ContentHTML   string       `json:"content_html,omitempty"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published,omitempty"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// JSON encodes the feed as JSON Feed 1.1.
+func (f *Feed) JSON() ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Language:    f.Language,
+		Items:       []jsonItem{},
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonAuthor{{Name: f.Author}}
+	}
+
+	for _, item := range f.Items {
+		entry := jsonItem{
+			ID:            item.ID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.HTML,
+			Summary:       item.Summary,
+			DatePublished: atomDate(item.Published),
+			DateModified:  atomDate(item.Updated),
+			Tags:          item.Categories,
+		}
+		if item.Author != "" {
+			entry.Authors = []jsonAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, entry)
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed: %w", err)
+	}
+	return out, nil
+}
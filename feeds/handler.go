@@ -0,0 +1,65 @@
+package feeds
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Format is a feed format served by Handler.
+type Format string
+
+const (
+	RSS  Format = "rss"
+	Atom Format = "atom"
+	JSON Format = "json"
+)
+
+// contentTypes are the media types of each format.
+var contentTypes = map[Format]string{
+	RSS:  "application/rss+xml; charset=utf-8",
+	Atom: "application/atom+xml; charset=utf-8",
+	JSON: "application/feed+json; charset=utf-8",
+}
+
+// Handler serves the feed of type T in the given format. The feed is built
+// on each request, so it follows content reloads, and its FeedURL is the
+// request path under cfg.BaseURL.
+//
+//	e.GET("/blog/rss.xml", feeds.Handler(feeds.RSS, cfg))
+func Handler[T any](format Format, cfg Config[T]) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		feed, err := New(cfg)
+		if err != nil {
+			return err
+		}
+		feed.FeedURL = strings.TrimSuffix(cfg.BaseURL, "/") + c.Request().URL.Path
+
+		var body []byte
+		switch format {
+		case RSS:
+			body, err = feed.RSS()
+		case Atom:
+			body, err = feed.Atom()
+		case JSON:
+			body, err = feed.JSON()
+		default:
+			err = fmt.Errorf("unknown feed format %q", format)
+		}
+		if err != nil {
+			return err
+		}
+
+		return c.Blob(http.StatusOK, contentTypes[format], body)
+	}
+}
+
+// Register mounts the RSS, Atom and JSON feeds of type T at prefix + rss.xml,
+// atom.xml and feed.json, e.g. /blog/rss.xml for a prefix of "/blog/".
+func Register[T any](e *echo.Echo, prefix string, cfg Config[T]) {
+	e.GET(prefix+"rss.xml", Handler(RSS, cfg))
+	e.GET(prefix+"atom.xml", Handler(Atom, cfg))
+	e.GET(prefix+"feed.json", Handler(JSON, cfg))
+}
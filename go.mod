@@ -5,6 +5,7 @@ go 1.24
 toolchain go1.24.4
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/a-h/templ v0.2.793
 	github.com/adrg/frontmatter v0.2.0
 	github.com/alecthomas/chroma/v2 v2.2.0
@@ -16,13 +17,13 @@ require (
 	github.com/yuin/goldmark-highlighting/v2 v2.0.0-20230729083705-37449abec8cc
 	github.com/zmtcreative/gm-alert-callouts v0.8.0
 	go.abhg.dev/goldmark/wikilink v0.6.0
+	golang.org/x/image v0.29.0
 	golang.org/x/mod v0.25.0
 	golang.org/x/text v0.27.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/BurntSushi/toml v0.3.1 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/dlclark/regexp2 v1.7.0 // indirect
 	github.com/forPelevin/gomoji v1.3.1 // indirect
@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -18,6 +20,27 @@ type ContentType struct {
 	Fields     []*ast.Field
 	DirName    string // The actual directory name found
 	Config     string // The config string found in the doc
+	Taxonomies []Taxonomy
+	Dated      bool // Has a content.Date field, so items are sorted by date
+	Data       bool // Loaded with content.LoadData, declared with kind=data
+	Refs       []Ref
+	Permalink  string // Slug pattern, declared with permalink=
+}
+
+// Ref is a string or []string field tagged `ccf:"ref=Type"`, holding slugs
+// of items of another type. It gets a typed accessor on the item type.
+type Ref struct {
+	Field  string // Go field name, e.g. Author
+	Key    string // Frontmatter key, e.g. author
+	Target string // Referenced type, e.g. Author
+	Many   bool   // Whether the field is a []string
+}
+
+// Taxonomy is a field tagged `ccf:"taxonomy"`, used to generate term helpers.
+type Taxonomy struct {
+	Field    string // Go field name, e.g. Tags
+	Key      string // Frontmatter key, e.g. tags
+	Singular string // Singular field name, e.g. Tag
 }
 
 type ContentGenerator struct {
@@ -57,45 +80,55 @@ func (g *ContentGenerator) parseContentTypes(configPath string) ([]ContentType,
 			}
 
 			// Prefer the spec’s own doc, else the decl’s doc.
-			conf := make(map[string]string)
+			var conf map[string]string
 			if gDecl.Doc != nil {
 				doc := strings.TrimSpace(gDecl.Doc.Text())
 				slog.Debug("Found struct doc", "name", tSpec.Name.Name, "doc", doc)
 
-				strs := strings.Split(doc, ":")
-				for i, s := range strs {
-					if s == "ccf" {
-						if len(strs) < i+1 {
-							return nil, fmt.Errorf("incorrect struct doc format: %s", doc)
-						}
-
-						confStr := strings.Trim(strs[i+1], "\"")
-
-						slog.Debug("Found struct doc", "name", tSpec.Name.Name, "doc", doc, "conf", confStr)
-
-						for s := range strings.SplitSeq(confStr, " ") {
-							kv := strings.Split(s, "=")
-							if len(kv) != 2 {
-								return nil, fmt.Errorf("incorrect struct doc format: %s", doc)
-							}
-							conf[kv[0]] = kv[1]
-						}
-						break
-					}
+				var err error
+				conf, err = parseDirective(doc)
+				if err != nil {
+					return nil, err
 				}
 			}
 
 			slog.Debug("Found struct", "name", tSpec.Name.Name, "conf", conf)
 
+			if kind := conf["kind"]; kind != "" && kind != "data" {
+				return nil, fmt.Errorf("unknown kind %q for %s, expected data", kind, tSpec.Name.Name)
+			}
+
+			refs, err := findRefs(tStruct.Fields.List)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ref in %s: %w", tSpec.Name.Name, err)
+			}
+
 			types = append(types, ContentType{
 				Name:       tSpec.Name.Name,
 				PluralName: tSpec.Name.Name,
 				Fields:     tStruct.Fields.List,
 				DirName:    conf["dir"],
+				Taxonomies: findTaxonomies(tStruct.Fields.List),
+				Dated:      hasDateField(tStruct.Fields.List),
+				Data:       conf["kind"] == "data",
+				Refs:       refs,
+				Permalink:  conf["permalink"],
 			})
 		}
 	}
 
+	names := make(map[string]bool, len(types))
+	for _, t := range types {
+		names[t.Name] = true
+	}
+	for _, t := range types {
+		for _, ref := range t.Refs {
+			if !names[ref.Target] {
+				return nil, fmt.Errorf("%s.%s references unknown type %s", t.Name, ref.Field, ref.Target)
+			}
+		}
+	}
+
 	// var types []ContentType
 	// ast.Inspect(node, func(n ast.Node) bool {
 	// 	if typeSpec, ok := n.(*ast.TypeSpec); ok {
@@ -114,6 +147,146 @@ func (g *ContentGenerator) parseContentTypes(configPath string) ([]ContentType,
 	return types, nil
 }
 
+// parseDirective parses the "ccf:" line of a struct doc, e.g.
+// `ccf:dir=posts permalink=:year/:title`, into its key=value pairs.
+func parseDirective(doc string) (map[string]string, error) {
+	conf := make(map[string]string)
+	for line := range strings.SplitSeq(doc, "\n") {
+		confStr, ok := strings.CutPrefix(strings.TrimSpace(line), "ccf:")
+		if !ok {
+			continue
+		}
+		confStr = strings.Trim(confStr, "\"")
+
+		slog.Debug("Found struct directive", "doc", doc, "conf", confStr)
+
+		for s := range strings.FieldsSeq(confStr) {
+			k, v, ok := strings.Cut(s, "=")
+			if !ok {
+				return nil, fmt.Errorf("incorrect struct doc format: %s", doc)
+			}
+			conf[k] = v
+		}
+		break
+	}
+	return conf, nil
+}
+
+// fieldTag returns the struct tag of a field.
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// fieldKey returns the frontmatter key of a field, as the content package
+// decodes it.
+func fieldKey(name string, tag reflect.StructTag) string {
+	key, _, _ := strings.Cut(tag.Get("yaml"), ",")
+	if key == "" {
+		return strings.ToLower(name)
+	}
+	return key
+}
+
+// hasCCFOption reports whether the ccf tag of a field contains option.
+func hasCCFOption(tag reflect.StructTag, option string) bool {
+	for opt := range strings.SplitSeq(tag.Get("ccf"), ",") {
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
+
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+func findTaxonomies(fields []*ast.Field) []Taxonomy {
+	var taxonomies []Taxonomy
+	for _, field := range fields {
+		tag := fieldTag(field)
+		if !hasCCFOption(tag, "taxonomy") {
+			continue
+		}
+		for _, name := range field.Names {
+			taxonomies = append(taxonomies, Taxonomy{
+				Field:    name.Name,
+				Key:      fieldKey(name.Name, tag),
+				Singular: singular(name.Name),
+			})
+		}
+	}
+	return taxonomies
+}
+
+// ccfOptionValue returns the value of a key=value option in the ccf tag of a field.
+func ccfOptionValue(tag reflect.StructTag, key string) string {
+	for opt := range strings.SplitSeq(tag.Get("ccf"), ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(opt), "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+func findRefs(fields []*ast.Field) ([]Ref, error) {
+	var refs []Ref
+	for _, field := range fields {
+		tag := fieldTag(field)
+		target := ccfOptionValue(tag, "ref")
+		if target == "" {
+			continue
+		}
+
+		typ := field.Type
+		array, many := typ.(*ast.ArrayType)
+		if many && array.Len == nil {
+			typ = array.Elt
+		}
+		if ident, ok := typ.(*ast.Ident); !ok || ident.Name != "string" {
+			return nil, fmt.Errorf("ref field must be a string or []string")
+		}
+
+		for _, name := range field.Names {
+			refs = append(refs, Ref{
+				Field:  name.Name,
+				Key:    fieldKey(name.Name, tag),
+				Target: target,
+				Many:   many,
+			})
+		}
+	}
+	return refs, nil
+}
+
+// hasDateField reports whether a field has the content.Date type, whatever
+// the package is imported as.
+func hasDateField(fields []*ast.Field) bool {
+	for _, field := range fields {
+		typ := field.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		if sel, ok := typ.(*ast.SelectorExpr); ok && sel.Sel.Name == "Date" {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *ContentGenerator) findMatchingDir(t ContentType, entries []os.DirEntry) (string, bool) {
 	singular := strings.ToLower(t.Name)
 	plural := singular + "s"
@@ -183,13 +356,25 @@ func (g *ContentGenerator) Generate() error {
 		dirs = append(dirs, t.DirName)
 	}
 
+	dated, markdown := false, false
+	for _, t := range types {
+		dated = dated || t.Dated
+		markdown = markdown || !t.Data
+	}
+
 	// Create template data
 	data := struct {
-		Types []ContentType
-		Dirs  string
+		Types      []ContentType
+		Dirs       string
+		ContentDir string
+		Dated      bool
+		Markdown   bool
 	}{
-		Types: types,
-		Dirs:  strings.Join(dirs, " "),
+		Types:      types,
+		Dirs:       strings.Join(dirs, " "),
+		ContentDir: filepath.ToSlash(g.ContentDir),
+		Dated:      dated,
+		Markdown:   markdown,
 	}
 
 	// Read template file
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"text/template"
 	"unicode"
@@ -24,8 +25,14 @@ type PageRoute struct {
 	HasDELETE     bool
 	Params        []string
 	Component     string
+	// HasPageParam is set for routes with a [page] segment, whose value
+	// is passed to the handlers as an int.
+	HasPageParam bool
 }
 
+// pageParam is the route parameter parsed as a page number.
+const pageParam = "page"
+
 // PagesGenerator handles the code generation for routes
 type PagesGenerator struct {
 	PagesDir    string
@@ -164,6 +171,7 @@ func (g *PagesGenerator) parseRouteFromFilename(filename string) (PageRoute, err
 		HasDELETE:     hasDelete,
 		Params:        params,
 		Component:     component,
+		HasPageParam:  slices.Contains(params, pageParam),
 	}, nil
 }
 
@@ -203,14 +211,18 @@ func (g *PagesGenerator) generateRouterCode(routes []PageRoute) error {
 	}
 	defer f.Close()
 
+	hasPageParam := slices.ContainsFunc(routes, func(r PageRoute) bool { return r.HasPageParam })
+
 	data := struct {
-		PackageName string
-		PagesImport string
-		Routes      []PageRoute
+		PackageName  string
+		PagesImport  string
+		Routes       []PageRoute
+		HasPageParam bool
 	}{
-		PackageName: g.PackageName,
-		PagesImport: g.pagesImport,
-		Routes:      routes,
+		PackageName:  g.PackageName,
+		PagesImport:  g.pagesImport,
+		Routes:       routes,
+		HasPageParam: hasPageParam,
 	}
 
 	if err := tmpl.Execute(f, data); err != nil {
@@ -0,0 +1,174 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxURLs is the most URLs a single sitemap may list.
+const MaxURLs = 50000
+
+// Config describes the sitemap of a site.
+type Config struct {
+	// BaseURL is the absolute URL of the site, e.g. "https://example.com".
+	BaseURL string
+	Sources []Source
+	// PerSitemap is the number of URLs per sitemap, MaxURLs if 0. Above it,
+	// sitemap.xml is a sitemap index of /sitemap/1.xml, /sitemap/2.xml, ...
+	PerSitemap int
+}
+
+func (cfg Config) urls() ([]URL, error) {
+	var urls []URL
+	for _, source := range cfg.Sources {
+		u, err := source()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sitemap URLs: %w", err)
+		}
+		urls = append(urls, u...)
+	}
+	return urls, nil
+}
+
+func (cfg Config) perSitemap() int {
+	if cfg.PerSitemap <= 0 || cfg.PerSitemap > MaxURLs {
+		return MaxURLs
+	}
+	return cfg.PerSitemap
+}
+
+// Register mounts /sitemap.xml and, for large sites, the /sitemap/:n.xml
+// sitemaps it indexes.
+//
+//	sitemap.Register(e, sitemap.Config{
+//		BaseURL: "https://example.com",
+//		Sources: []sitemap.Source{
+//			sitemap.Paths(router.StaticRoutes...),
+//			sitemap.Collection[content.Post]("/blog/:slug"),
+//		},
+//	})
+func Register(e *echo.Echo, cfg Config) {
+	e.GET("/sitemap.xml", IndexHandler(cfg))
+	e.GET("/sitemap/:file", PartHandler(cfg))
+}
+
+// IndexHandler serves the sitemap, or a sitemap index if there are more
+// URLs than fit in one.
+func IndexHandler(cfg Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		urls, err := cfg.urls()
+		if err != nil {
+			return err
+		}
+
+		per := cfg.perSitemap()
+		if len(urls) <= per {
+			return xmlBlob(c)(encodeURLSet(cfg.BaseURL, urls))
+		}
+
+		var locs []string
+		var lastMods []time.Time
+		for start, n := 0, 1; start < len(urls); start, n = start+per, n+1 {
+			locs = append(locs, fmt.Sprintf("/sitemap/%d.xml", n))
+			lastMods = append(lastMods, latest(urls[start:min(start+per, len(urls))]))
+		}
+		return xmlBlob(c)(encodeIndex(cfg.BaseURL, locs, lastMods))
+	}
+}
+
+// PartHandler serves the numbered sitemaps of a sitemap index, as
+// /sitemap/:file with a file such as 2.xml.
+func PartHandler(cfg Config) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		n, err := strconv.Atoi(strings.TrimSuffix(c.Param("file"), ".xml"))
+		if err != nil || n < 1 {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+
+		urls, err := cfg.urls()
+		if err != nil {
+			return err
+		}
+
+		per := cfg.perSitemap()
+		start := (n - 1) * per
+		if start >= len(urls) {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		return xmlBlob(c)(encodeURLSet(cfg.BaseURL, urls[start:min(start+per, len(urls))]))
+	}
+}
+
+func latest(urls []URL) time.Time {
+	var t time.Time
+	for _, u := range urls {
+		if u.LastMod.After(t) {
+			t = u.LastMod
+		}
+	}
+	return t
+}
+
+// xmlBlob returns a function writing the result of an encoder to c.
+func xmlBlob(c echo.Context) func([]byte, error) error {
+	return func(body []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		return c.Blob(http.StatusOK, "application/xml; charset=utf-8", body)
+	}
+}
+
+// Robots describes a robots.txt file.
+type Robots struct {
+	// UserAgent defaults to "*".
+	UserAgent string
+	Allow     []string
+	Disallow  []string
+	// Sitemaps are absolute sitemap URLs, such as
+	// "https://example.com/sitemap.xml".
+	Sitemaps []string
+}
+
+// String returns the robots.txt file.
+func (r Robots) String() string {
+	var b strings.Builder
+	agent := r.UserAgent
+	if agent == "" {
+		agent = "*"
+	}
+	fmt.Fprintf(&b, "User-agent: %s\n", agent)
+	for _, path := range r.Allow {
+		fmt.Fprintf(&b, "Allow: %s\n", path)
+	}
+	for _, path := range r.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	if len(r.Allow) == 0 && len(r.Disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	if len(r.Sitemaps) > 0 {
+		b.WriteString("\n")
+	}
+	for _, sitemap := range r.Sitemaps {
+		fmt.Fprintf(&b, "Sitemap: %s\n", sitemap)
+	}
+	return b.String()
+}
+
+// RobotsHandler serves r as robots.txt.
+//
+//	e.GET("/robots.txt", sitemap.RobotsHandler(sitemap.Robots{
+//		Sitemaps: []string{"https://example.com/sitemap.xml"},
+//	}))
+func RobotsHandler(r Robots) echo.HandlerFunc {
+	body := r.String()
+	return func(c echo.Context) error {
+		return c.String(http.StatusOK, body)
+	}
+}
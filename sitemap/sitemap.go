@@ -0,0 +1,204 @@
+// Package sitemap serves sitemap.xml and robots.txt for the static routes
+// of a site and the pages of its content collections.
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.quinn.io/ccf/content"
+)
+
+// URL is an entry of a sitemap.
+type URL struct {
+	// Loc is a path such as /blog/hello, or an absolute URL.
+	Loc        string
+	LastMod    time.Time
+	ChangeFreq string
+	Priority   float64
+}
+
+// Source lists URLs for a sitemap. It is called on every request, so that
+// sitemaps follow content reloads.
+type Source func() ([]URL, error)
+
+// Paths lists fixed paths, such as the StaticRoutes generated with the page
+// routes.
+func Paths(paths ...string) Source {
+	return func() ([]URL, error) {
+		urls := make([]URL, len(paths))
+		for i, path := range paths {
+			urls[i] = URL{Loc: path}
+		}
+		return urls, nil
+	}
+}
+
+// Collection lists the visible items of type T, replacing :slug in pattern
+// with their escaped slug, e.g. "/blog/:slug". LastMod is the frontmatter field
+// tagged `sitemap:"lastmod"` or keyed lastmod or updated, if it holds a
+// later date than the item's Date.
+func Collection[T any](pattern string) Source {
+	return func() ([]URL, error) {
+		items, err := content.GetItems[T]()
+		if err != nil {
+			return nil, err
+		}
+
+		field, hasField := lastModField(reflect.TypeOf((*T)(nil)).Elem())
+		urls := make([]URL, 0, len(items))
+		for _, item := range items {
+			url := URL{
+				Loc:     strings.ReplaceAll(pattern, ":slug", escapePath(item.Slug)),
+				LastMod: item.Date,
+			}
+			if hasField {
+				if t := timeValue(reflect.ValueOf(item.Meta).Field(field)); t.After(url.LastMod) {
+					url.LastMod = t
+				}
+			}
+			urls = append(urls, url)
+		}
+		return urls, nil
+	}
+}
+
+// Terms lists the terms of a taxonomy of type T, replacing :term in
+// pattern with their escaped slug, e.g. "/tags/:term".
+func Terms[T any](taxonomy, pattern string) Source {
+	return func() ([]URL, error) {
+		terms, err := content.Terms[T](taxonomy)
+		if err != nil {
+			return nil, err
+		}
+
+		urls := make([]URL, len(terms))
+		for i, term := range terms {
+			urls[i] = URL{Loc: strings.ReplaceAll(pattern, ":term", escapePath(term.Slug))}
+		}
+		return urls, nil
+	}
+}
+
+// escapePath escapes each segment of a slash-separated slug for use in a
+// URL path, e.g. "2024/café" becomes "2024/caf%C3%A9".
+func escapePath(slug string) string {
+	segments := strings.Split(slug, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// lastModField returns the index of the field of struct type t holding the
+// last modification date.
+func lastModField(t reflect.Type) (int, bool) {
+	if t.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("sitemap") == "lastmod" {
+			return i, true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if key == "" {
+			key = strings.ToLower(f.Name)
+		}
+		if key == "lastmod" || key == "updated" {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func timeValue(v reflect.Value) time.Time {
+	switch t := v.Interface().(type) {
+	case time.Time:
+		return t
+	case content.Date:
+		return t.Time
+	}
+	return time.Time{}
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	NS      string   `xml:"xmlns,attr"`
+	URLs    []xmlURL `xml:"url"`
+}
+
+type xmlURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	NS       string       `xml:"xmlns,attr"`
+	Sitemaps []xmlSitemap `xml:"sitemap"`
+}
+
+type xmlSitemap struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// encodeURLSet encodes urls as a sitemap, with locations under baseURL.
+func encodeURLSet(baseURL string, urls []URL) ([]byte, error) {
+	set := urlSet{NS: sitemapNS}
+	for _, u := range urls {
+		x := xmlURL{
+			Loc:        absolute(baseURL, u.Loc),
+			LastMod:    lastMod(u.LastMod),
+			ChangeFreq: u.ChangeFreq,
+		}
+		if u.Priority > 0 {
+			x.Priority = fmt.Sprintf("%.1f", u.Priority)
+		}
+		set.URLs = append(set.URLs, x)
+	}
+	return marshal(set)
+}
+
+// encodeIndex encodes a sitemap index of the given sitemap locations.
+func encodeIndex(baseURL string, locs []string, lastMods []time.Time) ([]byte, error) {
+	index := sitemapIndex{NS: sitemapNS}
+	for i, loc := range locs {
+		index.Sitemaps = append(index.Sitemaps, xmlSitemap{Loc: absolute(baseURL, loc), LastMod: lastMod(lastMods[i])})
+	}
+	return marshal(index)
+}
+
+func marshal(doc any) ([]byte, error) {
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sitemap: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func absolute(baseURL, loc string) string {
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		return loc
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(loc, "/")
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,125 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"go.quinn.io/ccf/content"
+)
+
+type Post struct {
+	Title   string       `yaml:"title"`
+	Date    content.Date `yaml:"date"`
+	Updated content.Date `yaml:"updated"`
+}
+
+func serve(t *testing.T, cfg Config, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"posts/first.md":  &fstest.MapFile{Data: []byte("---\ntitle: First\ndate: 2024-01-01\nupdated: 2024-03-01\n---\nFirst.")},
+		"posts/second.md": &fstest.MapFile{Data: []byte("---\ntitle: Second\ndate: 2024-02-01\n---\nSecond.")},
+	}
+	if err := content.LoadItems[Post](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	e := echo.New()
+	Register(e, cfg)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+var testConfig = Config{
+	BaseURL: "https://example.com/",
+	Sources: []Source{
+		Paths("/", "/about"),
+		Collection[Post]("/blog/:slug"),
+	},
+}
+
+func TestSitemap(t *testing.T) {
+	rec := serve(t, testConfig, "/sitemap.xml")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "application/xml") {
+		t.Fatalf("Unexpected response: %d %s", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+
+	var set urlSet
+	if err := xml.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Invalid sitemap: %v", err)
+	}
+	lastMods := map[string]string{}
+	for _, u := range set.URLs {
+		lastMods[u.Loc] = u.LastMod
+	}
+	for loc, lastMod := range map[string]string{
+		"https://example.com/":            "",
+		"https://example.com/about":       "",
+		"https://example.com/blog/first":  "2024-03-01T00:00:00Z",
+		"https://example.com/blog/second": "2024-02-01T00:00:00Z",
+	} {
+		if got, ok := lastMods[loc]; !ok || got != lastMod {
+			t.Errorf("Expected %s with lastmod %q, got %q (listed: %v)", loc, lastMod, got, ok)
+		}
+	}
+}
+
+func TestSitemapIndex(t *testing.T) {
+	cfg := testConfig
+	cfg.PerSitemap = 3
+
+	rec := serve(t, cfg, "/sitemap.xml")
+	var index sitemapIndex
+	if err := xml.Unmarshal(rec.Body.Bytes(), &index); err != nil {
+		t.Fatalf("Invalid sitemap index: %v", err)
+	}
+	if len(index.Sitemaps) != 2 || index.Sitemaps[1].Loc != "https://example.com/sitemap/2.xml" {
+		t.Fatalf("Expected 2 sitemaps, got %+v", index.Sitemaps)
+	}
+
+	rec = serve(t, cfg, "/sitemap/2.xml")
+	var set urlSet
+	if err := xml.Unmarshal(rec.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Invalid sitemap: %v", err)
+	}
+	if len(set.URLs) != 1 {
+		t.Errorf("Expected 1 URL in the second sitemap, got %+v", set.URLs)
+	}
+
+	if rec := serve(t, cfg, "/sitemap/3.xml"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 past the last sitemap, got %d", rec.Code)
+	}
+}
+
+func TestCollectionEscapesSlugs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"posts/2024/café au lait.md": &fstest.MapFile{Data: []byte("---\ntitle: Café\n---\nCafé.")},
+	}
+	if err := content.LoadItems[Post](fsys, "posts"); err != nil {
+		t.Fatalf("Failed to load items: %v", err)
+	}
+
+	urls, err := Collection[Post]("/blog/:slug")()
+	if err != nil {
+		t.Fatalf("Failed to list URLs: %v", err)
+	}
+	if len(urls) != 1 || urls[0].Loc != "/blog/2024/caf%C3%A9%20au%20lait" {
+		t.Errorf("Expected an escaped Loc, got %+v", urls)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	got := Robots{
+		Disallow: []string{"/admin"},
+		Sitemaps: []string{"https://example.com/sitemap.xml"},
+	}.String()
+	want := "User-agent: *\nDisallow: /admin\n\nSitemap: https://example.com/sitemap.xml\n"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}